@@ -3,4 +3,22 @@ package config
 type API struct {
 	HTTPHeaders     map[string][]string // HTTP headers to return with the API.
 	EnableTokenAuth bool
-}
\ No newline at end of file
+
+	// CORS configures the Access-Control-* headers of the API. It replaces
+	// the corresponding entries of HTTPHeaders.
+	CORS *CORS `json:",omitempty"`
+
+	// TLS enables HTTPS on the API listener when set.
+	TLS *TLS `json:",omitempty"`
+}
+
+// Headers returns the HTTP headers to return with the API, combining
+// HTTPHeaders and the CORS policy.
+func (a *API) Headers() (map[string][]string, error) {
+	return mergeCORSHeaders("API", a.HTTPHeaders, a.CORS)
+}
+
+// Validate checks the HTTP headers and the CORS policy of the API.
+func (a *API) Validate() error {
+	return validateCORSConfig("API", a.HTTPHeaders, a.CORS)
+}
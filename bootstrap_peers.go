@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	peer "github.com/libp2p/go-libp2p/core/peer"
 	ma "github.com/multiformats/go-multiaddr"
@@ -57,8 +58,18 @@ var DefaultTestnetBootstrapAddresses = []string{
 // ErrInvalidPeerAddr signals an address is not a valid peer address.
 var ErrInvalidPeerAddr = errors.New("invalid peer address")
 
+// BootstrapPeers parses the bootstrap list. /dnsaddr/<domain> entries
+// without a peer ID name their peers only in DNS and are skipped, use
+// ResolveBootstrapPeers to include them.
 func (c *Config) BootstrapPeers() ([]peer.AddrInfo, error) {
-	return ParseBootstrapPeers(c.Bootstrap)
+	addrs := make([]string, 0, len(c.Bootstrap))
+	for _, addr := range c.Bootstrap {
+		if maddr, err := ma.NewMultiaddr(addr); err == nil && isDNSAddrDomain(maddr) {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return ParseBootstrapPeers(addrs)
 }
 
 // DefaultBootstrapPeers returns the (parsed) set of default bootstrap peers.
@@ -116,3 +127,249 @@ func BootstrapPeerStrings(bps []peer.AddrInfo) []string {
 	}
 	return bpss
 }
+
+// ValidateBootstrap checks that every entry of a bootstrap list is a
+// multiaddr ending in a /p2p/ component, or a /dnsaddr/<domain> address
+// whose TXT records name the peers.
+func ValidateBootstrap(addrs []string) error {
+	for _, addr := range addrs {
+		if _, err := parseBootstrapAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddBootstrap appends addresses to the bootstrap list, skipping the ones
+// already in it. Nothing is added if any address is invalid.
+func (c *Config) AddBootstrap(addrs ...string) error {
+	parsed := make([]ma.Multiaddr, len(addrs))
+	for i, addr := range addrs {
+		var err error
+		if parsed[i], err = parseBootstrapAddr(addr); err != nil {
+			return err
+		}
+	}
+	for _, maddr := range parsed {
+		if !c.hasBootstrap(maddr) {
+			c.Bootstrap = append(c.Bootstrap, maddr.String())
+		}
+	}
+	return nil
+}
+
+// RemoveBootstrap removes entries from the bootstrap list. Each argument is
+// either a full bootstrap address, which is removed, or a peer ID, with or
+// without a /p2p/ prefix, in which case every address of that peer is
+// removed. It returns the removed entries.
+func (c *Config) RemoveBootstrap(addrsOrIDs ...string) ([]string, error) {
+	ids := map[peer.ID]bool{}
+	var maddrs []ma.Multiaddr
+	for _, a := range addrsOrIDs {
+		if id, err := peer.Decode(strings.TrimPrefix(a, "/p2p/")); err == nil {
+			ids[id] = true
+			continue
+		}
+		maddr, err := parseBootstrapAddr(a)
+		if err != nil {
+			return nil, err
+		}
+		maddrs = append(maddrs, maddr)
+	}
+
+	var kept, removed []string
+	for _, entry := range c.Bootstrap {
+		if bootstrapEntryMatches(entry, ids, maddrs) {
+			removed = append(removed, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	c.Bootstrap = kept
+	return removed, nil
+}
+
+// DedupeBootstrap removes duplicate addresses from the bootstrap list and
+// groups the addresses of each peer together, keeping peers in the order
+// they first appear. /dnsaddr/ addresses without a peer ID follow the peers.
+// It returns the number of entries removed.
+func (c *Config) DedupeBootstrap() (int, error) {
+	var order []peer.ID
+	var domains []ma.Multiaddr
+	byPeer := map[peer.ID][]ma.Multiaddr{}
+	for _, entry := range c.Bootstrap {
+		maddr, err := parseBootstrapAddr(entry)
+		if err != nil {
+			return 0, err
+		}
+		if isDNSAddrDomain(maddr) {
+			if !containsMultiaddr(domains, maddr) {
+				domains = append(domains, maddr)
+			}
+			continue
+		}
+		info, err := peer.AddrInfoFromP2pAddr(maddr)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := byPeer[info.ID]; !ok {
+			order = append(order, info.ID)
+		}
+		addrs := byPeer[info.ID]
+		if len(info.Addrs) == 0 {
+			// a bare /p2p/ address, kept once per peer.
+			info.Addrs = []ma.Multiaddr{nil}
+		}
+		for _, a := range info.Addrs {
+			if !containsMultiaddr(addrs, a) {
+				addrs = append(addrs, a)
+			}
+		}
+		byPeer[info.ID] = addrs
+	}
+
+	out := make([]string, 0, len(c.Bootstrap))
+	for _, id := range order {
+		p2p, err := ma.NewComponent("p2p", id.String())
+		if err != nil {
+			return 0, err
+		}
+		for _, a := range byPeer[id] {
+			if a == nil {
+				out = append(out, p2p.String())
+			} else {
+				out = append(out, a.Encapsulate(p2p).String())
+			}
+		}
+	}
+	for _, d := range domains {
+		out = append(out, d.String())
+	}
+	removed := len(c.Bootstrap) - len(out)
+	c.Bootstrap = out
+	return removed, nil
+}
+
+// DefaultBootstrapAddressesFor returns the default bootstrap addresses of a
+// network.
+func DefaultBootstrapAddressesFor(n Network) ([]string, error) {
+	switch n {
+	case NetworkMainnet:
+		return append([]string{}, DefaultBootstrapAddresses...), nil
+	case NetworkTestnet:
+		return append([]string{}, DefaultTestnetBootstrapAddresses...), nil
+	default:
+		return nil, fmt.Errorf("no default bootstrap peers for %s network", n)
+	}
+}
+
+// RestoreDefaultBootstrap replaces the bootstrap list with the defaults of
+// the config's network, as determined by CheckNetworkConsistency.
+func (c *Config) RestoreDefaultBootstrap() error {
+	n, err := c.CheckNetworkConsistency()
+	if err != nil {
+		return err
+	}
+	defaults, err := DefaultBootstrapAddressesFor(n)
+	if err != nil {
+		return err
+	}
+	c.Bootstrap = defaults
+	return nil
+}
+
+// BootstrapDiff lists how a bootstrap list differs from the defaults.
+type BootstrapDiff struct {
+	Missing []string // default addresses not in the list
+	Extra   []string // addresses in the list that are not defaults
+}
+
+// DiffBootstrap compares the bootstrap list with the defaults of the
+// config's network.
+func (c *Config) DiffBootstrap() (BootstrapDiff, error) {
+	var d BootstrapDiff
+	n, err := c.CheckNetworkConsistency()
+	if err != nil {
+		return d, err
+	}
+	defaults, err := DefaultBootstrapAddressesFor(n)
+	if err != nil {
+		return d, err
+	}
+	current := map[string]bool{}
+	for _, entry := range c.Bootstrap {
+		current[canonicalMultiaddr(entry)] = true
+	}
+	def := map[string]bool{}
+	for _, entry := range defaults {
+		def[canonicalMultiaddr(entry)] = true
+		if !current[canonicalMultiaddr(entry)] {
+			d.Missing = append(d.Missing, entry)
+		}
+	}
+	for _, entry := range c.Bootstrap {
+		if !def[canonicalMultiaddr(entry)] {
+			d.Extra = append(d.Extra, entry)
+		}
+	}
+	return d, nil
+}
+
+// parseBootstrapAddr parses a bootstrap entry, which is either a p2p address
+// or a /dnsaddr/<domain> address without a peer ID.
+func parseBootstrapAddr(addr string) (ma.Multiaddr, error) {
+	maddr, err := ma.NewMultiaddr(addr)
+	if err == nil && isDNSAddrDomain(maddr) {
+		return maddr, nil
+	}
+	return parseP2pAddr(addr)
+}
+
+func parseP2pAddr(addr string) (ma.Multiaddr, error) {
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %s", ErrInvalidPeerAddr, addr, err)
+	}
+	if _, err := peer.AddrInfoFromP2pAddr(maddr); err != nil {
+		return nil, fmt.Errorf("%w %q: must end with /p2p/<peer ID>", ErrInvalidPeerAddr, addr)
+	}
+	return maddr, nil
+}
+
+func (c *Config) hasBootstrap(maddr ma.Multiaddr) bool {
+	for _, entry := range c.Bootstrap {
+		if other, err := ma.NewMultiaddr(entry); err == nil && other.Equal(maddr) {
+			return true
+		}
+	}
+	return false
+}
+
+func bootstrapEntryMatches(entry string, ids map[peer.ID]bool, maddrs []ma.Multiaddr) bool {
+	maddr, err := ma.NewMultiaddr(entry)
+	if err != nil {
+		return false
+	}
+	if info, err := peer.AddrInfoFromP2pAddr(maddr); err == nil && ids[info.ID] {
+		return true
+	}
+	return containsMultiaddr(maddrs, maddr)
+}
+
+func containsMultiaddr(addrs []ma.Multiaddr, a ma.Multiaddr) bool {
+	for _, b := range addrs {
+		if (a == nil && b == nil) || (a != nil && b != nil && a.Equal(b)) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalMultiaddr returns the canonical string form of a multiaddr, or
+// the string itself if it does not parse.
+func canonicalMultiaddr(s string) string {
+	if maddr, err := ma.NewMultiaddr(s); err == nil {
+		return maddr.String()
+	}
+	return s
+}
@@ -40,3 +40,79 @@ func TestTestnetBoostrapPeerStrings(t *testing.T) {
 		}
 	}
 }
+
+func TestBootstrapManagement(t *testing.T) {
+	const (
+		a  = "/ip4/1.2.3.4/tcp/4001/p2p/16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5"
+		a2 = "/ip4/1.2.3.4/udp/4001/quic-v1/p2p/16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5"
+		b  = "/ip4/5.6.7.8/tcp/4001/p2p/16Uiu2HAmVSpShqGg8c7dEuG8qSWZjisx1rxNFwgAAi47HKHHXFr4"
+	)
+	cfg := &Config{}
+	if err := cfg.AddBootstrap(a, b, a); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Bootstrap) != 2 {
+		t.Fatalf("expected duplicates to be skipped, got %v", cfg.Bootstrap)
+	}
+	if err := cfg.AddBootstrap(a2, "/ip4/1.2.3.4/tcp/4001"); err == nil {
+		t.Fatal("expected an error for an address without /p2p/")
+	}
+	if len(cfg.Bootstrap) != 2 {
+		t.Fatal("expected nothing to be added when an address is invalid")
+	}
+
+	cfg.Bootstrap = []string{a, b, a2, a}
+	n, err := cfg.DedupeBootstrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(cfg.Bootstrap) != 3 || cfg.Bootstrap[0] != a || cfg.Bootstrap[1] != a2 || cfg.Bootstrap[2] != b {
+		t.Fatalf("unexpected deduped list %v", cfg.Bootstrap)
+	}
+
+	removed, err := cfg.RemoveBootstrap("16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || len(cfg.Bootstrap) != 1 || cfg.Bootstrap[0] != b {
+		t.Fatalf("expected both addresses of the peer to be removed, got %v", cfg.Bootstrap)
+	}
+	if removed, err := cfg.RemoveBootstrap(b); err != nil || len(removed) != 1 || len(cfg.Bootstrap) != 0 {
+		t.Fatalf("expected the address to be removed, got %v, %v", cfg.Bootstrap, err)
+	}
+	if _, err := cfg.RemoveBootstrap("not an address"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if err := ValidateBootstrap([]string{a, "/ip4/1.2.3.4/tcp/4001"}); err == nil {
+		t.Fatal("expected an error for an address without /p2p/")
+	}
+}
+
+func TestRestoreDefaultBootstrap(t *testing.T) {
+	cfg := &Config{}
+	cfg.Swarm.SwarmKey = DefaultTestnetSwarmKey
+	cfg.Bootstrap = []string{DefaultTestnetBootstrapAddresses[0], "/ip4/1.2.3.4/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"}
+
+	d, err := cfg.DiffBootstrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Missing) != len(DefaultTestnetBootstrapAddresses)-1 || len(d.Extra) != 1 {
+		t.Fatalf("unexpected diff %+v", d)
+	}
+
+	if err := cfg.RestoreDefaultBootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Bootstrap) != len(DefaultTestnetBootstrapAddresses) {
+		t.Fatalf("expected the testnet defaults, got %v", cfg.Bootstrap)
+	}
+	if d, err := cfg.DiffBootstrap(); err != nil || len(d.Missing)+len(d.Extra) != 0 {
+		t.Fatalf("expected no difference, got %+v, %v", d, err)
+	}
+
+	if err := (&Config{}).RestoreDefaultBootstrap(); err == nil {
+		t.Fatal("expected an error for an unknown network")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// RetiredBootstrapPeer identifies a bootstrap entry that is no longer
+// served. An Addr ending in /p2p/<peer ID> matches that exact entry. An Addr
+// without a peer ID matches every entry with exactly that transport address,
+// whatever its peer ID, and a bare /ip4/<host> or /ip6/<host> retires the
+// whole host, whatever its port.
+type RetiredBootstrapPeer struct {
+	Addr string
+}
+
+// RetiredBootstrapPeers lists the retired bootstrap peers of each network.
+// Entries using the /btfs/ protocol, which no longer parses, are always
+// retired.
+var RetiredBootstrapPeers = map[Network][]RetiredBootstrapPeer{
+	NetworkMainnet: {
+		{Addr: "/ip4/34.213.5.20/tcp/4001/p2p/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ"},
+		{Addr: "/ip4/52.77.240.134/tcp/4001/p2p/QmURPwdLYesWUDB66EGXvDvwcyV44rVRqV2iGNqKN24eVu"},
+		{Addr: "/ip4/3.126.224.22/tcp/4001/p2p/QmWTTmvchTodUaVvuKZMo67xk7ZgkxJf4nBo7SZry3vGU5"},
+		{Addr: "/ip4/18.194.71.27/tcp/4001/p2p/QmYHkY5CrWcvgaDo4PfvzTQgaZtfaqRGDjwW1MrHUj8cLK"},
+		{Addr: "/ip4/18.237.54.123/tcp/4001/p2p/QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g"},
+		{Addr: "/ip4/54.213.128.120/tcp/4001/p2p/QmWm3vBCRuZcJMUT9jDZysoYBb66aokmSReX26UaMk8qq5"},
+		{Addr: "/ip4/18.237.202.91/tcp/4001/p2p/QmbVFdiNkvxtc7Nni7yBWAgtHg8MuyhaZ5mDaYR2ZrhhvN"},
+		{Addr: "/ip4/13.229.45.41/tcp/4001/p2p/QmX7RZXh27AX8iv2BKLGMgPBiuUpEy8p4LFXgtXAfaZDn9"},
+		{Addr: "/ip4/54.254.227.188/tcp/4001/p2p/QmYqCq3PasrzLr3PxtLo5D6spEAJ836W9Re9Eo4zUou45U"},
+		{Addr: "/ip4/54.93.47.134/tcp/4001/p2p/QmeHaHe7WvjeY37z5MYC3qYQcQcuvDwUhwTXtP3KhKLXXK"},
+	},
+	NetworkTestnet: {
+		{Addr: "/ip4/52.57.56.230"},
+		{Addr: "/ip4/13.59.69.165"},
+		{Addr: "/ip4/13.229.73.63"},
+		{Addr: "/ip4/3.126.51.74"},
+	},
+}
+
+// IsRetiredBootstrap reports whether a bootstrap entry is retired on a
+// network.
+func IsRetiredBootstrap(n Network, entry string) bool {
+	maddr, err := ma.NewMultiaddr(entry)
+	if err != nil {
+		return strings.Contains(entry, "/btfs/")
+	}
+	transport, _ := peer.SplitAddr(maddr)
+	for _, r := range RetiredBootstrapPeers[n] {
+		retired, err := ma.NewMultiaddr(r.Addr)
+		if err != nil {
+			continue
+		}
+		if maddr.Equal(retired) {
+			return true
+		}
+		t, id := peer.SplitAddr(retired)
+		if id != "" || transport == nil {
+			continue
+		}
+		if transport.Equal(t) || isHostAddr(t) && hasHost(transport, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostAddr reports whether maddr is a bare /ip4/ or /ip6/ address.
+func isHostAddr(maddr ma.Multiaddr) bool {
+	first, rest := ma.SplitFirst(maddr)
+	if first == nil || rest != nil {
+		return false
+	}
+	code := first.Protocol().Code
+	return code == ma.P_IP4 || code == ma.P_IP6
+}
+
+// hasHost reports whether maddr starts with the host component.
+func hasHost(maddr, host ma.Multiaddr) bool {
+	first, _ := ma.SplitFirst(maddr)
+	return first != nil && first.Equal(host)
+}
+
+// migrateRetiredBootstrap removes the retired entries of a network from the
+// bootstrap list. If any were removed, the missing default peers of the
+// network are added; other entries are kept.
+func migrateRetiredBootstrap(cfg *Config, n Network) bool {
+	if SwarmKeyNetwork(cfg.Swarm.SwarmKey) != n {
+		return false
+	}
+	kept := make([]string, 0, len(cfg.Bootstrap))
+	for _, entry := range cfg.Bootstrap {
+		if !IsRetiredBootstrap(n, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(cfg.Bootstrap) {
+		return false
+	}
+	cfg.Bootstrap = kept
+	defaults, err := DefaultBootstrapAddressesFor(n)
+	if err != nil {
+		return true
+	}
+	for _, d := range defaults {
+		if maddr, err := ma.NewMultiaddr(d); err == nil && !cfg.hasBootstrap(maddr) {
+			cfg.Bootstrap = append(cfg.Bootstrap, d)
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package config
+
+import "testing"
+
+func TestIsRetiredBootstrap(t *testing.T) {
+	for entry, want := range map[string]bool{
+		"/ip4/34.213.5.20/tcp/4001/p2p/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ":  true,
+		"/ip4/34.213.5.20/tcp/4002/p2p/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ":  false,
+		"/ip4/134.213.5.20/tcp/4001/p2p/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ": false,
+		"/ip4/1.2.3.4/tcp/4001/btfs/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ":     true,
+		DefaultBootstrapAddresses[0]: false,
+	} {
+		if got := IsRetiredBootstrap(NetworkMainnet, entry); got != want {
+			t.Fatalf("%s: expected %t, got %t", entry, want, got)
+		}
+	}
+	for entry, want := range map[string]bool{
+		"/ip4/13.59.69.165/tcp/43113/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":       true,
+		"/ip4/13.59.69.165/tcp/4001/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":        true,
+		"/ip4/13.59.69.165/tcp/43113/ws/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":    true,
+		"/ip4/52.57.56.230/tcp/4001/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":        true,
+		"/ip4/3.126.51.74/udp/4001/quic-v1/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo": true,
+		"/ip4/52.57.56.2/tcp/4001/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":          false,
+		"/ip4/113.59.69.165/tcp/4001/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo":       false,
+	} {
+		if got := IsRetiredBootstrap(NetworkTestnet, entry); got != want {
+			t.Fatalf("%s: expected %t, got %t", entry, want, got)
+		}
+	}
+}
+
+func TestMigrateRetiredBootstrap(t *testing.T) {
+	const custom = "/ip4/9.9.9.9/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+	cfg := &Config{}
+	cfg.Swarm.SwarmKey = DefaultTestnetSwarmKey
+	cfg.Bootstrap = []string{
+		custom,
+		"/ip4/13.229.73.63/tcp/38869/p2p/16Uiu2HAmFFwNdgSoLhfgJUPEfPEVodppRxaeZBVpAvrH5s3qSkWo",
+		DefaultTestnetBootstrapAddresses[0],
+	}
+	if migrate_5_Bootstrap_node(cfg) {
+		t.Fatal("expected the mainnet migration to skip a testnet config")
+	}
+	if !migrate_7_Testnet_Bootstrap_node(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if cfg.Bootstrap[0] != custom || cfg.Bootstrap[1] != DefaultTestnetBootstrapAddresses[0] {
+		t.Fatalf("expected custom and current entries to be kept in order, got %v", cfg.Bootstrap[:2])
+	}
+	if len(cfg.Bootstrap) != len(DefaultTestnetBootstrapAddresses)+1 {
+		t.Fatalf("expected the testnet defaults to be added once, got %d entries", len(cfg.Bootstrap))
+	}
+	if migrate_7_Testnet_Bootstrap_node(cfg) || migrate_14_TestnetBootstrapNodes(cfg) {
+		t.Fatal("expected the migration to be idempotent")
+	}
+
+	cfg.Bootstrap = []string{custom}
+	if migrate_7_Testnet_Bootstrap_node(cfg) || len(cfg.Bootstrap) != 1 {
+		t.Fatal("expected a list without retired entries to be left alone")
+	}
+}
@@ -1,12 +1,21 @@
 package config
 
-var (
-	// chain ID
-	ethChainID      = int64(5)
-	tronChainID     = int64(100)
-	bttcChainID     = int64(199)
-	bttcTestChainID = int64(1029)
-	testChainID     = int64(1337)
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// chain ID
+const (
+	EthChainID      = int64(5)
+	TronChainID     = int64(100)
+	BttcChainID     = int64(199)
+	BttcTestChainID = int64(1029)
+	TestChainID     = int64(1337)
 )
 
 // the configuration of the local node's ChainInfo.
@@ -17,3 +26,155 @@ type ChainInfo struct {
 	VaultLogicAddress  string `json:",omitempty"`
 	Endpoint           string `json:",omitempty"`
 }
+
+// ChainSpec describes a chain known to BTFS and its per-chain defaults.
+// Contract addresses are empty when no deployment is pinned by this package.
+type ChainSpec struct {
+	ChainID int64
+	Name    string
+	Testnet bool
+
+	Endpoint           string
+	CurrentFactory     string
+	PriceOracleAddress string
+	VaultLogicAddress  string
+}
+
+// Chains is the registry of known chains, keyed by chain ID.
+var Chains = map[int64]ChainSpec{
+	EthChainID: {
+		ChainID: EthChainID,
+		Name:    "ETH Goerli",
+		Testnet: true,
+	},
+	TronChainID: {
+		ChainID: TronChainID,
+		Name:    "TRON",
+	},
+	BttcChainID: {
+		ChainID:  BttcChainID,
+		Name:     "BTTC",
+		Endpoint: "https://rpc.bittorrentchain.io/",
+	},
+	BttcTestChainID: {
+		ChainID:  BttcTestChainID,
+		Name:     "BTTC Donau testnet",
+		Testnet:  true,
+		Endpoint: "https://pre-rpc.bittorrentchain.io/",
+	},
+	TestChainID: {
+		ChainID:  TestChainID,
+		Name:     "local test chain",
+		Testnet:  true,
+		Endpoint: "http://127.0.0.1:8545",
+	},
+}
+
+// LookupChain returns the registry entry of a chain ID.
+func LookupChain(chainID int64) (ChainSpec, bool) {
+	cs, ok := Chains[chainID]
+	return cs, ok
+}
+
+// FillDefaults sets every empty field of a known chain to its registry
+// default. Unknown chains are left untouched.
+func (c *ChainInfo) FillDefaults() {
+	cs, ok := LookupChain(c.ChainId)
+	if !ok {
+		return
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = cs.Endpoint
+	}
+	if c.CurrentFactory == "" {
+		c.CurrentFactory = cs.CurrentFactory
+	}
+	if c.PriceOracleAddress == "" {
+		c.PriceOracleAddress = cs.PriceOracleAddress
+	}
+	if c.VaultLogicAddress == "" {
+		c.VaultLogicAddress = cs.VaultLogicAddress
+	}
+}
+
+// Validate checks the contract addresses, including their EIP-55 checksum
+// when they are mixed-case, and the endpoint URL. An unset ChainId is
+// accepted and resolved by the node.
+func (c *ChainInfo) Validate() error {
+	if c.ChainId < 0 {
+		return fmt.Errorf("invalid ChainInfo.ChainId %d", c.ChainId)
+	}
+	for _, a := range []struct {
+		field, value string
+	}{
+		{"CurrentFactory", c.CurrentFactory},
+		{"PriceOracleAddress", c.PriceOracleAddress},
+		{"VaultLogicAddress", c.VaultLogicAddress},
+	} {
+		if a.value == "" {
+			continue
+		}
+		if err := ValidateHexAddress(a.value); err != nil {
+			return fmt.Errorf("ChainInfo.%s: %s", a.field, err)
+		}
+	}
+	if c.Endpoint != "" {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid ChainInfo.Endpoint %q: %s", c.Endpoint, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "ws", "wss":
+		default:
+			return fmt.Errorf("invalid ChainInfo.Endpoint %q: expected an http(s) or ws(s) URL", c.Endpoint)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid ChainInfo.Endpoint %q: missing host", c.Endpoint)
+		}
+	}
+	return nil
+}
+
+// ValidateHexAddress checks that addr is a 0x-prefixed 20-byte hex address.
+// Mixed-case addresses must carry a valid EIP-55 checksum.
+func ValidateHexAddress(addr string) error {
+	if !strings.HasPrefix(addr, "0x") && !strings.HasPrefix(addr, "0X") {
+		return fmt.Errorf("invalid address %q: missing 0x prefix", addr)
+	}
+	b, err := hex.DecodeString(addr[2:])
+	if err != nil || len(b) != 20 {
+		return fmt.Errorf("invalid address %q: expected 20 hex-encoded bytes", addr)
+	}
+	digits := addr[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if ChecksumHexAddress(b) != "0x"+digits {
+		return fmt.Errorf("invalid address %q: bad EIP-55 checksum", addr)
+	}
+	return nil
+}
+
+// ChecksumHexAddress formats a 20-byte address as a 0x-prefixed, EIP-55
+// mixed-case hex string.
+func ChecksumHexAddress(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
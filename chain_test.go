@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestChecksumHexAddress(t *testing.T) {
+	// test vectors from EIP-55
+	for _, addr := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		b, err := hex.DecodeString(addr[2:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ChecksumHexAddress(b); got != addr {
+			t.Fatalf("expected %s, got %s", addr, got)
+		}
+		if err := ValidateHexAddress(addr); err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateHexAddress(strings.ToLower(addr)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, addr := range []string{
+		"0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed", // bad checksum
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",   // no prefix
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",   // too short
+		"0xZZAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", // not hex
+	} {
+		if err := ValidateHexAddress(addr); err == nil {
+			t.Fatalf("expected %s to be rejected", addr)
+		}
+	}
+}
+
+func TestChainInfo(t *testing.T) {
+	c := ChainInfo{ChainId: BttcChainID}
+	c.FillDefaults()
+	if c.Endpoint != Chains[BttcChainID].Endpoint {
+		t.Fatalf("expected the default endpoint, got %q", c.Endpoint)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = ChainInfo{ChainId: BttcTestChainID, Endpoint: "http://localhost:8545"}
+	c.FillDefaults()
+	if c.Endpoint != "http://localhost:8545" {
+		t.Fatal("expected the configured endpoint to be preserved")
+	}
+
+	c.Endpoint = "localhost:8545"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an endpoint without a scheme to be rejected")
+	}
+
+	const factory = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	const vault = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	Chains[42] = ChainSpec{ChainID: 42, CurrentFactory: factory, VaultLogicAddress: factory}
+	c = ChainInfo{ChainId: 42, VaultLogicAddress: vault}
+	c.FillDefaults()
+	delete(Chains, 42)
+	if c.CurrentFactory != factory || c.VaultLogicAddress != vault {
+		t.Fatalf("expected the registry addresses to fill only empty fields, got %+v", c)
+	}
+
+	c = ChainInfo{ChainId: 42}
+	c.FillDefaults()
+	if c.Endpoint != "" {
+		t.Fatal("expected an unknown chain to be left untouched")
+	}
+	if _, ok := LookupChain(42); ok {
+		t.Fatal("expected chain 42 to be unknown")
+	}
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -139,3 +140,39 @@ func (c *Config) Clone() (*Config, error) {
 
 	return &newConfig, nil
 }
+
+// Validate checks the config for errors that would otherwise only surface
+// when the node starts, and returns all of them joined together.
+func (c *Config) Validate() error {
+	var errs []error
+	if _, err := c.Identity.Verify(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ValidateBootstrap(c.Bootstrap); err != nil {
+		errs = append(errs, fmt.Errorf("Bootstrap: %w", err))
+	}
+	if err := c.Peering.Validate(c.Identity.PeerID); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.Swarm.ResolveRelay(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, v := range []interface{ Validate() error }{
+		&c.Datastore,
+		&c.Ipns,
+		&c.Pubsub,
+		&c.Gateway,
+		&c.API,
+		&c.S3CompatibleAPI,
+		&c.Services,
+		&c.ChainInfo,
+	} {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := c.CheckNetworkConsistency(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
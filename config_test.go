@@ -1,6 +1,8 @@
 package config
 
 import (
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -27,3 +29,30 @@ func TestClone(t *testing.T) {
 		t.Fatal("HTTP headers not preserved")
 	}
 }
+
+func TestValidate(t *testing.T) {
+	cfg, err := Init(io.Discard, 2048, "", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected the default config to be valid: %s", err)
+	}
+
+	other, err := IdentityConfig(io.Discard, 2048, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Identity.PeerID = other.PeerID
+	cfg.Swarm.Transports.Network.Relay = False
+	cfg.Swarm.RelayClient.Enabled = True
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"Identity.PeerID", "Swarm.RelayClient.Enabled"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected the error to mention %s, got %q", want, err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corsAllowOrigin      = "Access-Control-Allow-Origin"
+	corsAllowMethods     = "Access-Control-Allow-Methods"
+	corsAllowHeaders     = "Access-Control-Allow-Headers"
+	corsExposeHeaders    = "Access-Control-Expose-Headers"
+	corsAllowCredentials = "Access-Control-Allow-Credentials"
+	corsMaxAge           = "Access-Control-Max-Age"
+)
+
+// CORS is a cross-origin resource sharing policy. It is translated into the
+// Access-Control-* response headers of the API, the gateway and the
+// S3-compatible API.
+type CORS struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin
+	// requests. An entry is either "*", an origin such as
+	// "https://example.com:8080", or an origin with a wildcard subdomain
+	// such as "https://*.example.com".
+	AllowedOrigins []string
+
+	// AllowedMethods lists the HTTP methods allowed in cross-origin requests.
+	AllowedMethods []string `json:",omitempty"`
+
+	// AllowedHeaders lists the request headers allowed in cross-origin
+	// requests.
+	AllowedHeaders []string `json:",omitempty"`
+
+	// ExposedHeaders lists the response headers exposed to cross-origin
+	// callers.
+	ExposedHeaders []string `json:",omitempty"`
+
+	// AllowCredentials allows cross-origin requests to carry cookies and
+	// authorization headers. It cannot be combined with the "*" origin.
+	AllowCredentials bool `json:",omitempty"`
+
+	// MaxAge is how long the result of a preflight request may be cached.
+	MaxAge *OptionalDuration `json:",omitempty"`
+}
+
+// DefaultGatewayCORS returns the CORS policy of a newly initialized gateway:
+// read-only access from any origin.
+func DefaultGatewayCORS() *CORS {
+	return &CORS{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET"},
+		AllowedHeaders: []string{"X-Requested-With", "Range", "User-Agent"},
+	}
+}
+
+// CORSFromHTTPHeaders extracts the CORS policy described by the
+// Access-Control-* entries of a raw header map. It returns nil when the map
+// has no such entries.
+func CORSFromHTTPHeaders(headers map[string][]string) *CORS {
+	var c CORS
+	found := false
+	for k, v := range headers {
+		switch http.CanonicalHeaderKey(k) {
+		case corsAllowOrigin:
+			c.AllowedOrigins = append(c.AllowedOrigins, v...)
+		case corsAllowMethods:
+			c.AllowedMethods = append(c.AllowedMethods, splitHeaderValues(v)...)
+		case corsAllowHeaders:
+			c.AllowedHeaders = append(c.AllowedHeaders, splitHeaderValues(v)...)
+		case corsExposeHeaders:
+			c.ExposedHeaders = append(c.ExposedHeaders, splitHeaderValues(v)...)
+		case corsAllowCredentials:
+			c.AllowCredentials = len(v) > 0 && strings.EqualFold(strings.TrimSpace(v[0]), "true")
+		case corsMaxAge:
+			if len(v) > 0 {
+				if secs, err := strconv.ParseInt(strings.TrimSpace(v[0]), 10, 64); err == nil {
+					c.MaxAge = NewOptionalDuration(time.Duration(secs) * time.Second)
+				}
+			}
+		default:
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return &c
+}
+
+// Validate checks the policy for malformed entries and for combinations that
+// browsers reject or that expose credentials to every origin.
+func (c *CORS) Validate() error {
+	if c == nil {
+		return nil
+	}
+	wildcard := false
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			wildcard = true
+			continue
+		}
+		if err := validateCORSOrigin(o); err != nil {
+			return err
+		}
+	}
+	if wildcard && len(c.AllowedOrigins) > 1 {
+		return fmt.Errorf("CORS origin \"*\" cannot be combined with other origins")
+	}
+	if wildcard && c.AllowCredentials {
+		return fmt.Errorf("CORS origin \"*\" cannot be combined with allowing credentials")
+	}
+	for _, m := range c.AllowedMethods {
+		if !isHTTPToken(m) || m != strings.ToUpper(m) {
+			return fmt.Errorf("invalid CORS method %q", m)
+		}
+	}
+	for _, h := range append(append([]string{}, c.AllowedHeaders...), c.ExposedHeaders...) {
+		if !isHTTPToken(h) {
+			return fmt.Errorf("invalid CORS header %q", h)
+		}
+	}
+	if c.MaxAge.WithDefault(0) < 0 {
+		return fmt.Errorf("CORS max age must not be negative")
+	}
+	return nil
+}
+
+// HTTPHeaders returns the static header map for the policy, in the format
+// used by the HTTPHeaders config fields.
+func (c *CORS) HTTPHeaders() map[string][]string {
+	h := map[string][]string{}
+	if c == nil {
+		return h
+	}
+	if len(c.AllowedOrigins) > 0 {
+		h[corsAllowOrigin] = append([]string{}, c.AllowedOrigins...)
+	}
+	c.setCommonHeaders(h)
+	return h
+}
+
+// AllowsOrigin reports whether a request with the given Origin header is
+// allowed by the policy.
+func (c *CORS) AllowsOrigin(origin string) bool {
+	if c == nil || origin == "" {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || matchCORSOrigin(strings.ToLower(o), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseHeaders evaluates the policy for a request with the given Origin
+// header and returns the CORS response headers, or false if the origin is
+// not allowed.
+func (c *CORS) ResponseHeaders(origin string) (map[string][]string, bool) {
+	if !c.AllowsOrigin(origin) {
+		return nil, false
+	}
+	h := map[string][]string{}
+	if len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" && !c.AllowCredentials {
+		h[corsAllowOrigin] = []string{"*"}
+	} else {
+		h[corsAllowOrigin] = []string{origin}
+		h["Vary"] = []string{"Origin"}
+	}
+	c.setCommonHeaders(h)
+	return h, true
+}
+
+func (c *CORS) setCommonHeaders(h map[string][]string) {
+	if len(c.AllowedMethods) > 0 {
+		h[corsAllowMethods] = append([]string{}, c.AllowedMethods...)
+	}
+	if len(c.AllowedHeaders) > 0 {
+		h[corsAllowHeaders] = append([]string{}, c.AllowedHeaders...)
+	}
+	if len(c.ExposedHeaders) > 0 {
+		h[corsExposeHeaders] = append([]string{}, c.ExposedHeaders...)
+	}
+	if c.AllowCredentials {
+		h[corsAllowCredentials] = []string{"true"}
+	}
+	if !c.MaxAge.IsDefault() {
+		h[corsMaxAge] = []string{strconv.FormatInt(int64(c.MaxAge.WithDefault(0).Seconds()), 10)}
+	}
+}
+
+// equal reports whether both policies allow the same origins, methods and
+// headers, ignoring order and case where HTTP does.
+func (c *CORS) equal(o *CORS) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	return sameStrings(c.AllowedOrigins, o.AllowedOrigins, strings.ToLower) &&
+		sameStrings(c.AllowedMethods, o.AllowedMethods, strings.ToUpper) &&
+		sameStrings(c.AllowedHeaders, o.AllowedHeaders, http.CanonicalHeaderKey) &&
+		sameStrings(c.ExposedHeaders, o.ExposedHeaders, http.CanonicalHeaderKey) &&
+		c.AllowCredentials == o.AllowCredentials &&
+		c.MaxAge.WithDefault(-1) == o.MaxAge.WithDefault(-1)
+}
+
+// mergeCORSHeaders returns the headers to send for a listener configured
+// with both a raw header map and a CORS policy. The policy owns the
+// Access-Control-* headers, defining them in both places is an error.
+func mergeCORSHeaders(section string, headers map[string][]string, cors *CORS) (map[string][]string, error) {
+	out := make(map[string][]string, len(headers))
+	for k, v := range headers {
+		if cors != nil && isCORSHeader(k) {
+			return nil, fmt.Errorf("%s.HTTPHeaders[%q] conflicts with %s.CORS", section, k, section)
+		}
+		out[k] = append([]string{}, v...)
+	}
+	if cors != nil {
+		for k, v := range cors.HTTPHeaders() {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// validateCORSConfig validates a listener's CORS policy together with the
+// Access-Control-* entries of its raw header map.
+func validateCORSConfig(section string, headers map[string][]string, cors *CORS) error {
+	if _, err := mergeCORSHeaders(section, headers, cors); err != nil {
+		return err
+	}
+	if err := cors.Validate(); err != nil {
+		return fmt.Errorf("%s.CORS: %s", section, err)
+	}
+	if err := CORSFromHTTPHeaders(headers).Validate(); err != nil {
+		return fmt.Errorf("%s.HTTPHeaders: %s", section, err)
+	}
+	return nil
+}
+
+func isCORSHeader(k string) bool {
+	return strings.HasPrefix(http.CanonicalHeaderKey(k), "Access-Control-")
+}
+
+func validateCORSOrigin(o string) error {
+	u, err := url.Parse(o)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid CORS origin %q: must be of the form scheme://host[:port]", o)
+	}
+	host := u.Hostname()
+	if strings.Contains(host, "*") {
+		if !strings.HasPrefix(host, "*.") || strings.Contains(host[2:], "*") || strings.Count(host[2:], ".") < 1 {
+			return fmt.Errorf("invalid CORS origin %q: wildcards must cover a subdomain of a registered domain", o)
+		}
+	}
+	return nil
+}
+
+func matchCORSOrigin(pattern, origin string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	i := strings.Index(pattern, "://*.")
+	if i < 0 {
+		return pattern == origin
+	}
+	prefix, suffix := pattern[:i+3], pattern[i+4:]
+	return strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+		len(origin) > len(prefix)+len(suffix) && !strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@")
+}
+
+func isHTTPToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitHeaderValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func sameStrings(a, b []string, normalize func(string) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	na := make([]string, len(a))
+	nb := make([]string, len(b))
+	for i := range a {
+		na[i] = normalize(a[i])
+		nb[i] = normalize(b[i])
+	}
+	sort.Strings(na)
+	sort.Strings(nb)
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCORSValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cors  CORS
+		valid bool
+	}{
+		"default gateway":       {cors: *DefaultGatewayCORS(), valid: true},
+		"explicit origins":      {cors: CORS{AllowedOrigins: []string{"https://example.com", "http://localhost:3000"}, AllowCredentials: true}, valid: true},
+		"wildcard subdomain":    {cors: CORS{AllowedOrigins: []string{"https://*.example.com"}}, valid: true},
+		"any origin with creds": {cors: CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+		"any origin and more":   {cors: CORS{AllowedOrigins: []string{"*", "https://example.com"}}},
+		"origin with path":      {cors: CORS{AllowedOrigins: []string{"https://example.com/app"}}},
+		"wildcard tld":          {cors: CORS{AllowedOrigins: []string{"https://*.com"}}},
+		"lower-case method":     {cors: CORS{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"get"}}},
+		"invalid header":        {cors: CORS{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"X Bad"}}},
+		"negative max age":      {cors: CORS{AllowedOrigins: []string{"*"}, MaxAge: NewOptionalDuration(-time.Second)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.cors.Validate()
+			if tc.valid && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	c := &CORS{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowCredentials: true,
+		MaxAge:           NewOptionalDuration(10 * time.Minute),
+	}
+
+	h := c.HTTPHeaders()
+	if h[corsMaxAge][0] != "600" || h[corsAllowCredentials][0] != "true" {
+		t.Fatalf("unexpected headers: %v", h)
+	}
+	if !CORSFromHTTPHeaders(h).equal(c) {
+		t.Fatalf("expected the policy to round-trip through its headers, got %+v", CORSFromHTTPHeaders(h))
+	}
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com":       true,
+		"https://a.b.example.com":       true,
+		"https://example.com":           false,
+		"http://app.example.com":        false,
+		"https://evil.com/.example.com": false,
+		"":                              false,
+	} {
+		h, ok := c.ResponseHeaders(origin)
+		if ok != allowed {
+			t.Fatalf("expected origin %q allowed=%v", origin, allowed)
+		}
+		if ok && h[corsAllowOrigin][0] != origin {
+			t.Fatalf("expected the origin to be echoed, got %v", h[corsAllowOrigin])
+		}
+	}
+
+	h, ok := DefaultGatewayCORS().ResponseHeaders("https://example.com")
+	if !ok || h[corsAllowOrigin][0] != "*" {
+		t.Fatalf("expected a wildcard origin, got %v", h)
+	}
+}
+
+func TestCORSMerge(t *testing.T) {
+	api := API{
+		HTTPHeaders: map[string][]string{"X-Custom": {"1"}},
+		CORS:        &CORS{AllowedOrigins: []string{"https://example.com"}},
+	}
+	h, err := api.Headers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"X-Custom":      {"1"},
+		corsAllowOrigin: {"https://example.com"},
+	}
+	if !reflect.DeepEqual(h, expected) {
+		t.Fatalf("expected %v, got %v", expected, h)
+	}
+
+	api.HTTPHeaders[corsAllowOrigin] = []string{"*"}
+	if err := api.Validate(); err == nil {
+		t.Fatal("expected a conflict between HTTPHeaders and CORS")
+	}
+
+	api = API{HTTPHeaders: map[string][]string{
+		corsAllowOrigin:      {"*"},
+		corsAllowCredentials: {"true"},
+	}}
+	if err := api.Validate(); err == nil {
+		t.Fatal("expected raw headers allowing credentials from any origin to be rejected")
+	}
+}
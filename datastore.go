@@ -2,16 +2,30 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 // DefaultDataStoreDirectory is the directory to store all the local IPFS data.
 const DefaultDataStoreDirectory = "datastore"
 
+// DefaultStorageMax is the default value for Datastore.StorageMax. The
+// storage host profiles raise it to DefaultHostStorageMax unless it was
+// changed or set by the storage-autosize profile.
+const DefaultStorageMax = 10 * GB
+
+// DefaultHostStorageMax is the Datastore.StorageMax set by the storage host
+// profiles.
+const DefaultHostStorageMax = 1 * TB
+
 // Datastore tracks the configuration of the datastore.
 type Datastore struct {
-	StorageMax         string // in B, kB, kiB, MB, ...
-	StorageGCWatermark int64  // in percentage to multiply on StorageMax
-	GCPeriod           string // in ns, us, ms, s, m, h
+	StorageMax         ByteSize          // e.g. 10GB, 512GiB
+	StorageGCWatermark int64             // in percentage to multiply on StorageMax
+	GCPeriod           *OptionalDuration `json:",omitempty"` // e.g. 1h, 30m
+
+	// AutoStorageMax records that StorageMax was sized to the disk by the
+	// storage-autosize profile, so the storage host profiles keep it.
+	AutoStorageMax bool `json:",omitempty"`
 
 	// deprecated fields, use Spec
 	Type   string           `json:",omitempty"`
@@ -19,7 +33,7 @@ type Datastore struct {
 	NoSync bool             `json:",omitempty"`
 	Params *json.RawMessage `json:",omitempty"`
 
-	Spec map[string]interface{}
+	Spec DatastoreSpec
 
 	HashOnRead      bool
 	BloomFilterSize int
@@ -30,3 +44,15 @@ type Datastore struct {
 func DataStorePath(configroot string) (string, error) {
 	return Path(configroot, DefaultDataStoreDirectory)
 }
+
+// Validate checks that the GC period is positive.
+func (d *Datastore) Validate() error {
+	return d.validateGCPeriod()
+}
+
+func (d *Datastore) validateGCPeriod() error {
+	if p := d.GCPeriod.WithDefault(DefaultGCPeriod); p <= 0 {
+		return fmt.Errorf("Datastore.GCPeriod must be positive, got %s", p)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DatastoreMountChange describes how the data stored under a mountpoint of
+// the old spec moves to the new spec. Old fields are empty for mounts only
+// in the new spec.
+type DatastoreMountChange struct {
+	Mountpoint    string
+	OldType       string
+	OldPath       string
+	NewMountpoint string // the mount of the new spec that stores the keys
+	NewType       string
+	NewPath       string
+}
+
+// Changed reports whether the data has to be copied to a new datastore.
+func (c DatastoreMountChange) Changed() bool {
+	return c.Mountpoint != c.NewMountpoint || c.OldType != c.NewType || c.OldPath != c.NewPath
+}
+
+// DatastoreConversionPlan describes an offline conversion of the datastore
+// from one Datastore.Spec to another.
+type DatastoreConversionPlan struct {
+	Mounts []DatastoreMountChange
+	// CreatePaths are the datastore directories of the new spec, relative
+	// to the datastore root, that the conversion must create.
+	CreatePaths []string
+	// EstimatedSize is the space needed for the copied data, measured as
+	// the current size of the datastores that change.
+	EstimatedSize ByteSize
+	// DiskSpec is the content of the datastore_spec file for the new spec.
+	DiskSpec []byte
+}
+
+// PlanDatastoreConversion compares two datastore specs, measures the
+// datastores that change under DataStorePath(configroot) and returns the
+// conversion plan. New datastores must not reuse the directory of a
+// datastore they replace.
+func PlanDatastoreConversion(configroot string, oldSpec, newSpec DatastoreSpec) (*DatastoreConversionPlan, error) {
+	root, err := DataStorePath(configroot)
+	if err != nil {
+		return nil, err
+	}
+	oldDisk, err := oldSpec.Canonical()
+	if err != nil {
+		return nil, fmt.Errorf("old spec: %s", err)
+	}
+	newDisk, err := newSpec.Canonical()
+	if err != nil {
+		return nil, fmt.Errorf("new spec: %s", err)
+	}
+	oldMounts := diskSpecMounts(oldDisk)
+	newMounts := diskSpecMounts(newDisk)
+
+	p := &DatastoreConversionPlan{}
+	if p.DiskSpec, err = json.Marshal(newDisk); err != nil {
+		return nil, err
+	}
+
+	oldPaths := map[string]bool{}
+	for _, om := range oldMounts {
+		oldPaths[om.path] = true
+	}
+	targets := map[string]bool{}
+	for _, om := range oldMounts {
+		nm, ok := newMounts.covering(om.mountpoint)
+		if !ok {
+			return nil, fmt.Errorf("keys under %s have no destination in the new spec", om.mountpoint)
+		}
+		targets[nm.mountpoint] = true
+		c := DatastoreMountChange{
+			Mountpoint:    om.mountpoint,
+			OldType:       om.typ,
+			OldPath:       om.path,
+			NewMountpoint: nm.mountpoint,
+			NewType:       nm.typ,
+			NewPath:       nm.path,
+		}
+		if !c.Changed() && !reflect.DeepEqual(om.spec, nm.spec) {
+			return nil, fmt.Errorf("datastore at %s changes its parameters in place", om.path)
+		}
+		if c.Changed() {
+			size, err := dirSize(filepath.Join(root, om.path))
+			if err != nil {
+				return nil, err
+			}
+			p.EstimatedSize += size
+		}
+		p.Mounts = append(p.Mounts, c)
+	}
+	for _, nm := range newMounts {
+		if !targets[nm.mountpoint] {
+			p.Mounts = append(p.Mounts, DatastoreMountChange{NewMountpoint: nm.mountpoint, NewType: nm.typ, NewPath: nm.path})
+		}
+	}
+	for _, c := range p.Mounts {
+		if !c.Changed() {
+			continue
+		}
+		if oldPaths[c.NewPath] {
+			return nil, fmt.Errorf("new %s datastore at %s would overwrite an existing datastore", c.NewType, c.NewPath)
+		}
+		if !containsString(p.CreatePaths, c.NewPath) {
+			p.CreatePaths = append(p.CreatePaths, c.NewPath)
+		}
+	}
+	return p, nil
+}
+
+type diskMount struct {
+	mountpoint string
+	typ        string
+	path       string
+	spec       map[string]interface{}
+}
+
+type diskMounts []diskMount
+
+// diskSpecMounts lists the leaf datastores of a disk spec by mountpoint,
+// longest mountpoint first.
+func diskSpecMounts(disk DatastoreSpec) diskMounts {
+	leaves := []map[string]interface{}{disk}
+	if disk["type"] == "mount" {
+		leaves = leaves[:0]
+		for _, m := range disk["mounts"].([]interface{}) {
+			leaves = append(leaves, m.(map[string]interface{}))
+		}
+	}
+	out := make(diskMounts, 0, len(leaves))
+	for _, l := range leaves {
+		mp, ok := l["mountpoint"].(string)
+		if !ok {
+			mp = "/"
+		}
+		spec := map[string]interface{}{}
+		for k, v := range l {
+			if k != "mountpoint" {
+				spec[k] = v
+			}
+		}
+		out = append(out, diskMount{mountpoint: mp, typ: l["type"].(string), path: l["path"].(string), spec: spec})
+	}
+	sort.Slice(out, func(i, j int) bool { return len(out[i].mountpoint) > len(out[j].mountpoint) })
+	return out
+}
+
+// covering returns the mount that stores keys under mountpoint, and false if
+// no mount does.
+func (ms diskMounts) covering(mountpoint string) (diskMount, bool) {
+	for _, m := range ms {
+		if m.mountpoint == "/" || mountpoint == m.mountpoint || strings.HasPrefix(mountpoint, m.mountpoint+"/") {
+			return m, true
+		}
+	}
+	return diskMount{}, false
+}
+
+// dirSize returns the total size of the files under dir, or zero if it does
+// not exist.
+func dirSize(dir string) (ByteSize, error) {
+	var size ByteSize
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += ByteSize(fi.Size())
+		}
+		return nil
+	})
+	return size, err
+}
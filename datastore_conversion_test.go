@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDatastoreDiskSpec(t *testing.T) {
+	p, err := PlanDatastoreConversion(t.TempDir(), flatfsSpec(), flatfsSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"mounts":[{"mountpoint":"/blocks","path":"blocks","shardFunc":"/repo/flatfs/shard/v1/next-to-last/2","type":"flatfs"},{"mountpoint":"/","path":"datastore","type":"levelds"}],"type":"mount"}`
+	if string(p.DiskSpec) != want {
+		t.Fatalf("expected %s, got %s", want, p.DiskSpec)
+	}
+	for _, c := range p.Mounts {
+		if c.Changed() {
+			t.Fatalf("expected no change, got %+v", c)
+		}
+	}
+	if len(p.CreatePaths) != 0 || p.EstimatedSize != 0 {
+		t.Fatalf("expected an empty plan, got %+v", p)
+	}
+}
+
+func TestPlanDatastoreConversion(t *testing.T) {
+	root := t.TempDir()
+	blocks := filepath.Join(root, DefaultDataStoreDirectory, "blocks", "AB")
+	if err := os.MkdirAll(blocks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(blocks, "block.data"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := PlanDatastoreConversion(root, flatfsSpec(), badgerSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.DiskSpec) != `{"path":"badgerds","type":"badgerds"}` {
+		t.Fatalf("unexpected disk spec %s", p.DiskSpec)
+	}
+	if len(p.Mounts) != 2 {
+		t.Fatalf("expected two mount changes, got %+v", p.Mounts)
+	}
+	for _, c := range p.Mounts {
+		if !c.Changed() || c.NewMountpoint != "/" || c.NewType != "badgerds" {
+			t.Fatalf("unexpected change %+v", c)
+		}
+	}
+	if p.Mounts[0].Mountpoint != "/blocks" || p.Mounts[0].OldType != "flatfs" {
+		t.Fatalf("unexpected blocks change %+v", p.Mounts[0])
+	}
+	if len(p.CreatePaths) != 1 || p.CreatePaths[0] != "badgerds" {
+		t.Fatalf("unexpected paths %v", p.CreatePaths)
+	}
+	if p.EstimatedSize != 4096 {
+		t.Fatalf("expected 4096 bytes, got %d", p.EstimatedSize)
+	}
+
+	back, err := PlanDatastoreConversion(root, badgerSpec(), flatfsSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.CreatePaths) != 2 {
+		t.Fatalf("expected blocks and datastore to be created, got %v", back.CreatePaths)
+	}
+
+	inPlace := flatfsSpec()
+	leveldb := inPlace["mounts"].([]interface{})[1].(map[string]interface{})["child"].(map[string]interface{})
+	leveldb["type"] = "badgerds"
+	if _, err := PlanDatastoreConversion(root, flatfsSpec(), inPlace); err == nil {
+		t.Fatal("expected an error for a new datastore reusing an old path")
+	}
+	if _, err := PlanDatastoreConversion(root, flatfsSpec(), map[string]interface{}{"type": "mem"}); err == nil {
+		t.Fatal("expected an error for an unsupported datastore")
+	}
+
+	blocksOnly := flatfsSpec()
+	blocksOnly["mounts"] = blocksOnly["mounts"].([]interface{})[:1]
+	if _, err := PlanDatastoreConversion(root, flatfsSpec(), blocksOnly); err == nil || !strings.Contains(err.Error(), "no destination") {
+		t.Fatalf("expected an error for keys under / without a destination, got %v", err)
+	}
+}
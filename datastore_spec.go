@@ -0,0 +1,176 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DatastoreSpecFile is the name of the file in the repo that records the
+// on-disk layout of the datastore.
+const DatastoreSpecFile = "datastore_spec"
+
+// DatastoreSpec is the datastore configuration, a tree of datastores such as
+// mount, measure, flatfs, levelds and badgerds.
+type DatastoreSpec map[string]interface{}
+
+// Canonical returns the part of the spec that determines the on-disk layout,
+// as go-btfs writes it to the datastore_spec file: wrapper datastores such as
+// measure are removed, leaves keep only their type, path and, for flatfs,
+// shardFunc, and mounts are listed with their mountpoint in reverse order.
+// Mounts cannot be nested. Runtime options such as sync or compression are dropped.
+func (s DatastoreSpec) Canonical() (DatastoreSpec, error) {
+	t, _ := s["type"].(string)
+	switch t {
+	case "mount":
+		entries, ok := s["mounts"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("mount datastore has no mounts")
+		}
+		mounts := make([]interface{}, 0, len(entries))
+		for _, e := range entries {
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid mount %v", e)
+			}
+			mp, ok := m["mountpoint"].(string)
+			if !ok || !strings.HasPrefix(mp, "/") {
+				return nil, fmt.Errorf("invalid mountpoint %v", m["mountpoint"])
+			}
+			d, err := DatastoreSpec(m).Canonical()
+			if err != nil {
+				return nil, err
+			}
+			if d["type"] == "mount" {
+				return nil, fmt.Errorf("nested mount datastore at %s is not supported", mp)
+			}
+			d["mountpoint"] = mp
+			mounts = append(mounts, map[string]interface{}(d))
+		}
+		sort.Slice(mounts, func(i, j int) bool {
+			return mounts[i].(map[string]interface{})["mountpoint"].(string) > mounts[j].(map[string]interface{})["mountpoint"].(string)
+		})
+		return DatastoreSpec{"type": "mount", "mounts": mounts}, nil
+	case "measure", "log":
+		child, ok := s["child"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s datastore has no child", t)
+		}
+		return DatastoreSpec(child).Canonical()
+	case "flatfs", "levelds", "badgerds":
+		path, ok := s["path"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("%s datastore has no path", t)
+		}
+		d := DatastoreSpec{"type": t, "path": path}
+		if t == "flatfs" {
+			shard, ok := s["shardFunc"].(string)
+			if !ok || shard == "" {
+				return nil, fmt.Errorf("flatfs datastore has no shardFunc")
+			}
+			d["shardFunc"] = shard
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unsupported datastore type %q", t)
+	}
+}
+
+// Fingerprint returns the canonical spec encoded as JSON with sorted keys,
+// which is the content go-btfs writes to the datastore_spec file.
+func (s DatastoreSpec) Fingerprint() (string, error) {
+	c, err := s.Canonical()
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Diff compares the on-disk layouts of two specs and describes each
+// difference, or returns nil if the same repo can be opened with both.
+func (s DatastoreSpec) Diff(other DatastoreSpec) ([]string, error) {
+	a, err := s.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	b, err := other.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	am, bm := diskSpecMounts(a), diskSpecMounts(b)
+	if (a["type"] == "mount") != (b["type"] == "mount") {
+		return []string{fmt.Sprintf("datastore type changes from %s to %s", a["type"], b["type"])}, nil
+	}
+
+	var diffs []string
+	byMountpoint := map[string]diskMount{}
+	for _, m := range bm {
+		byMountpoint[m.mountpoint] = m
+	}
+	for _, m := range am {
+		n, ok := byMountpoint[m.mountpoint]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("mount %s is removed", m.mountpoint))
+			continue
+		}
+		delete(byMountpoint, m.mountpoint)
+		keys := make([]string, 0, len(m.spec))
+		for k := range m.spec {
+			keys = append(keys, k)
+		}
+		for k := range n.spec {
+			if _, ok := m.spec[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !reflect.DeepEqual(m.spec[k], n.spec[k]) {
+				diffs = append(diffs, fmt.Sprintf("mount %s: %s changes from %v to %v", m.mountpoint, k, m.spec[k], n.spec[k]))
+			}
+		}
+	}
+	for _, m := range bm {
+		if _, ok := byMountpoint[m.mountpoint]; ok {
+			diffs = append(diffs, fmt.Sprintf("mount %s is added", m.mountpoint))
+		}
+	}
+	return diffs, nil
+}
+
+// CheckSpecFile compares Spec with the datastore_spec file of the repo at
+// configroot, and returns an error describing why the repo could not be
+// opened with Spec. A missing file is not an error, it is written when the
+// repo is opened.
+func (d *Datastore) CheckSpecFile(configroot string) error {
+	file, err := Path(configroot, DatastoreSpecFile)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var onDisk DatastoreSpec
+	if err := json.Unmarshal(b, &onDisk); err != nil {
+		return fmt.Errorf("invalid %s: %s", file, err)
+	}
+	diffs, err := onDisk.Diff(d.Spec)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("Datastore.Spec does not match %s: %s", file, strings.Join(diffs, "; "))
+	}
+	return nil
+}
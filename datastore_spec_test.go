@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDatastoreSpecFingerprint(t *testing.T) {
+	fp, err := DatastoreSpec(flatfsSpec()).Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"mounts":[{"mountpoint":"/blocks","path":"blocks","shardFunc":"/repo/flatfs/shard/v1/next-to-last/2","type":"flatfs"},{"mountpoint":"/","path":"datastore","type":"levelds"}],"type":"mount"}`
+	if fp != want {
+		t.Fatalf("expected %s, got %s", want, fp)
+	}
+
+	var onDisk DatastoreSpec
+	if err := json.Unmarshal([]byte(want), &onDisk); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := onDisk.Fingerprint(); err != nil || again != want {
+		t.Fatalf("expected the disk spec to be its own fingerprint, got %s, %v", again, err)
+	}
+
+	// Runtime options and mount order do not change the fingerprint.
+	spec := DatastoreSpec(flatfsSpec())
+	mounts := spec["mounts"].([]interface{})
+	mounts[0], mounts[1] = mounts[1], mounts[0]
+	mounts[1].(map[string]interface{})["child"].(map[string]interface{})["sync"] = false
+	if other, err := spec.Fingerprint(); err != nil || other != want {
+		t.Fatalf("expected the same fingerprint, got %s, %v", other, err)
+	}
+}
+
+func TestDatastoreSpecDiff(t *testing.T) {
+	flatfs := DatastoreSpec(flatfsSpec())
+	if diffs, err := flatfs.Diff(flatfsSpec()); err != nil || diffs != nil {
+		t.Fatalf("expected no differences, got %v, %v", diffs, err)
+	}
+
+	changed := DatastoreSpec(flatfsSpec())
+	blocks := changed["mounts"].([]interface{})[0].(map[string]interface{})["child"].(map[string]interface{})
+	blocks["shardFunc"] = "/repo/flatfs/shard/v1/prefix/2"
+	blocks["path"] = "blocks2"
+	diffs, err := flatfs.Diff(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 || !strings.Contains(diffs[0], "path changes from blocks to blocks2") || !strings.Contains(diffs[1], "shardFunc") {
+		t.Fatalf("unexpected differences %q", diffs)
+	}
+
+	diffs, err = flatfs.Diff(badgerSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || !strings.Contains(diffs[0], "from mount to badgerds") {
+		t.Fatalf("unexpected differences %q", diffs)
+	}
+
+	if _, err := flatfs.Diff(DatastoreSpec{"type": "flatfs"}); err == nil {
+		t.Fatal("expected an error for a flatfs datastore without a path")
+	}
+
+	nested := DatastoreSpec{
+		"type": "mount",
+		"mounts": []interface{}{
+			map[string]interface{}{
+				"mountpoint": "/blocks",
+				"type":       "measure",
+				"child":      flatfsSpec(),
+			},
+		},
+	}
+	if _, err := nested.Canonical(); err == nil {
+		t.Fatal("expected an error for a nested mount")
+	}
+	if _, err := flatfs.Diff(nested); err == nil {
+		t.Fatal("expected Diff to reject a nested mount")
+	}
+	root := t.TempDir()
+	fp, err := flatfs.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, DatastoreSpecFile), []byte(fp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Datastore{Spec: nested}).CheckSpecFile(root); err == nil {
+		t.Fatal("expected CheckSpecFile to reject a nested mount")
+	}
+}
+
+func TestCheckSpecFile(t *testing.T) {
+	root := t.TempDir()
+	ds := DefaultDatastoreConfig()
+	if err := ds.CheckSpecFile(root); err != nil {
+		t.Fatalf("expected a missing file to be accepted: %s", err)
+	}
+
+	fp, err := ds.Spec.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, DatastoreSpecFile), []byte(fp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.CheckSpecFile(root); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.Spec = badgerSpec()
+	if err := ds.CheckSpecFile(root); err == nil || !strings.Contains(err.Error(), DatastoreSpecFile) {
+		t.Fatalf("expected a mismatch error, got %v", err)
+	}
+}
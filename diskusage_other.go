@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package config
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func diskUsage(path string) (total, free ByteSize, err error) {
+	return 0, 0, fmt.Errorf("disk usage is not supported on %s", runtime.GOOS)
+}
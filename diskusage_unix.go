@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package config
+
+import "golang.org/x/sys/unix"
+
+func diskUsage(path string) (total, free ByteSize, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	return ByteSize(uint64(st.Blocks) * bsize), ByteSize(uint64(st.Bavail) * bsize), nil
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package config
+
+import "golang.org/x/sys/windows"
+
+func diskUsage(path string) (total, free ByteSize, err error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var available, size, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, &size, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return ByteSize(size), ByteSize(available), nil
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MaxDNSAddrLookups bounds the TXT lookups made to resolve one /dnsaddr/
+// address, which may point at further /dnsaddr/ addresses.
+const MaxDNSAddrLookups = 32
+
+const dnsaddrTXTPrefix = "dnsaddr="
+
+// TXTResolver looks up DNS TXT records.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewTXTResolver returns a resolver that follows DNS.Resolvers: names are
+// looked up with the DoH endpoint of the longest matching FQDN suffix, and
+// with the OS resolver if none matches and "." is not set.
+func NewTXTResolver(cfg DNS) (TXTResolver, error) {
+	r := &dnsResolver{resolvers: map[string]TXTResolver{}}
+	for domain, endpoint := range cfg.Resolvers {
+		if !strings.HasPrefix(endpoint, "https://") {
+			return nil, fmt.Errorf("DNS.Resolvers[%q]: only https:// (DoH) resolvers are supported, got %q", domain, endpoint)
+		}
+		if !strings.HasSuffix(domain, ".") {
+			domain += "."
+		}
+		r.resolvers[strings.ToLower(domain)] = &dohResolver{url: endpoint, client: http.DefaultClient}
+	}
+	return r, nil
+}
+
+type dnsResolver struct {
+	resolvers map[string]TXTResolver
+}
+
+func (r *dnsResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	fqdn := strings.ToLower(name)
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	best := ""
+	var resolver TXTResolver = net.DefaultResolver
+	for domain, res := range r.resolvers {
+		if (domain == "." || fqdn == domain || strings.HasSuffix(fqdn, "."+domain)) && len(domain) > len(best) {
+			best, resolver = domain, res
+		}
+	}
+	return resolver.LookupTXT(ctx, name)
+}
+
+// dohResolver looks up TXT records with DNS over HTTPS (RFC 8484).
+type dohResolver struct {
+	url    string
+	client *http.Client
+}
+
+func (r *dohResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET}},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH lookup of %s at %s: %s", name, r.url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+
+	var p dnsmessage.Parser
+	h, err := p.Start(body)
+	if err != nil {
+		return nil, err
+	}
+	if h.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DoH lookup of %s at %s: %s", name, r.url, h.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+	var txts []string
+	for {
+		ah, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			return txts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ah.Type != dnsmessage.TypeTXT {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		txt, err := p.TXTResource()
+		if err != nil {
+			return nil, err
+		}
+		txts = append(txts, strings.Join(txt.TXT, ""))
+	}
+}
+
+// ResolveBootstrapPeers parses a bootstrap list, resolving /dnsaddr/ entries
+// through their _dnsaddr TXT records, and groups the addresses by peer.
+// /dnsaddr/<domain> entries without a peer ID resolve to every peer of the
+// domain.
+func ResolveBootstrapPeers(ctx context.Context, r TXTResolver, addrs []string) ([]peer.AddrInfo, error) {
+	var maddrs []ma.Multiaddr
+	for _, addr := range addrs {
+		maddr, err := parseBootstrapAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !isDNSAddr(maddr) {
+			maddrs = append(maddrs, maddr)
+			continue
+		}
+		lookups := MaxDNSAddrLookups
+		resolved, err := resolveDNSAddr(ctx, r, maddr, &lookups)
+		if err != nil {
+			return nil, err
+		}
+		if len(resolved) == 0 {
+			return nil, fmt.Errorf("%s resolved to no addresses", addr)
+		}
+		maddrs = append(maddrs, resolved...)
+	}
+	return groupAddrInfos(maddrs)
+}
+
+// ResolveBootstrapPeers resolves the bootstrap list with the resolvers of
+// DNS.Resolvers. If a /dnsaddr/ entry cannot be resolved, the /dnsaddr/
+// entries are replaced with the hardcoded defaults of the config's network.
+func (c *Config) ResolveBootstrapPeers(ctx context.Context) ([]peer.AddrInfo, error) {
+	r, err := NewTXTResolver(c.DNS)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := ResolveBootstrapPeers(ctx, r, c.Bootstrap)
+	if err == nil {
+		return peers, nil
+	}
+
+	n, nerr := c.CheckNetworkConsistency()
+	if nerr != nil {
+		return nil, err
+	}
+	fallback, nerr := DefaultBootstrapAddressesFor(n)
+	if nerr != nil {
+		return nil, err
+	}
+	var static []string
+	for _, addr := range c.Bootstrap {
+		if maddr, perr := parseBootstrapAddr(addr); perr != nil {
+			return nil, perr
+		} else if !isDNSAddr(maddr) {
+			static = append(static, addr)
+		}
+	}
+	return ParseBootstrapPeers(append(static, fallback...))
+}
+
+func isDNSAddr(maddr ma.Multiaddr) bool {
+	first, _ := ma.SplitFirst(maddr)
+	return first != nil && first.Protocol().Code == ma.P_DNSADDR
+}
+
+// isDNSAddrDomain reports whether maddr is a /dnsaddr/<domain> address
+// without a peer ID.
+func isDNSAddrDomain(maddr ma.Multiaddr) bool {
+	_, rest := ma.SplitFirst(maddr)
+	return isDNSAddr(maddr) && rest == nil
+}
+
+// resolveDNSAddr resolves a /dnsaddr/<domain>[/p2p/<id>] address. Records are
+// filtered to the peer ID of the address, if it has one.
+func resolveDNSAddr(ctx context.Context, r TXTResolver, maddr ma.Multiaddr, lookups *int) ([]ma.Multiaddr, error) {
+	if *lookups <= 0 {
+		return nil, fmt.Errorf("too many /dnsaddr/ lookups resolving %s", maddr)
+	}
+	*lookups--
+
+	first, rest := ma.SplitFirst(maddr)
+	var id peer.ID
+	if rest != nil {
+		info, err := peer.AddrInfoFromP2pAddr(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid /dnsaddr/ address %s: %s", maddr, err)
+		}
+		id = info.ID
+	}
+	name := "_dnsaddr." + first.Value()
+	txts, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", maddr, err)
+	}
+
+	var out []ma.Multiaddr
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, dnsaddrTXTPrefix) {
+			continue
+		}
+		record, err := ma.NewMultiaddr(strings.TrimPrefix(txt, dnsaddrTXTPrefix))
+		if err != nil {
+			continue
+		}
+		info, err := peer.AddrInfoFromP2pAddr(record)
+		if err != nil || (id != "" && info.ID != id) {
+			continue
+		}
+		if isDNSAddr(record) {
+			nested, err := resolveDNSAddr(ctx, r, record, lookups)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nested...)
+			continue
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+// groupAddrInfos groups p2p addresses by peer, keeping the peers in the
+// order they first appear.
+func groupAddrInfos(maddrs []ma.Multiaddr) ([]peer.AddrInfo, error) {
+	var out []peer.AddrInfo
+	index := map[peer.ID]int{}
+	for _, maddr := range maddrs {
+		info, err := peer.AddrInfoFromP2pAddr(maddr)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[info.ID]
+		if !ok {
+			index[info.ID] = len(out)
+			out = append(out, *info)
+			continue
+		}
+		for _, a := range info.Addrs {
+			if !containsMultiaddr(out[i].Addrs, a) {
+				out[i].Addrs = append(out[i].Addrs, a)
+			}
+		}
+	}
+	return out, nil
+}
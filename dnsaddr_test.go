@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type stubTXTResolver map[string][]string
+
+func (r stubTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	txts, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return txts, nil
+}
+
+const (
+	testPeerA = "16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5"
+	testPeerB = "16Uiu2HAmVSpShqGg8c7dEuG8qSWZjisx1rxNFwgAAi47HKHHXFr4"
+)
+
+func TestResolveBootstrapPeers(t *testing.T) {
+	r := stubTXTResolver{
+		"_dnsaddr.bootstrap.example.com": {
+			"dnsaddr=/dnsaddr/eu.bootstrap.example.com/p2p/" + testPeerA,
+			"dnsaddr=/ip4/5.6.7.8/tcp/4001/p2p/" + testPeerB,
+			"v=spf1 -all",
+		},
+		"_dnsaddr.eu.bootstrap.example.com": {
+			"dnsaddr=/ip4/1.2.3.4/tcp/4001/p2p/" + testPeerA,
+			"dnsaddr=/ip4/1.2.3.4/udp/4001/quic-v1/p2p/" + testPeerA,
+			"dnsaddr=/ip4/5.6.7.8/tcp/4001/p2p/" + testPeerB,
+		},
+	}
+
+	peers, err := ResolveBootstrapPeers(context.Background(), r, []string{
+		"/dnsaddr/bootstrap.example.com/p2p/" + testPeerA,
+		"/dnsaddr/bootstrap.example.com/p2p/" + testPeerB,
+		"/ip4/9.9.9.9/tcp/4001/p2p/" + testPeerB,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("expected two peers, got %v", peers)
+	}
+	if peers[0].ID.String() != testPeerA || len(peers[0].Addrs) != 2 {
+		t.Fatalf("expected two addresses for peer A, got %v", peers[0])
+	}
+	if peers[1].ID.String() != testPeerB || len(peers[1].Addrs) != 2 {
+		t.Fatalf("expected two addresses for peer B, got %v", peers[1])
+	}
+
+	peers, err = ResolveBootstrapPeers(context.Background(), r, []string{"/dnsaddr/bootstrap.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || peers[0].ID.String() != testPeerA || len(peers[0].Addrs) != 2 || peers[1].ID.String() != testPeerB {
+		t.Fatalf("expected every peer of the domain, got %v", peers)
+	}
+
+	if _, err := ResolveBootstrapPeers(context.Background(), r, []string{"/dnsaddr/missing.example.com/p2p/" + testPeerA}); err == nil {
+		t.Fatal("expected an error for an unresolvable domain")
+	}
+
+	loop := stubTXTResolver{"_dnsaddr.loop.example.com": {"dnsaddr=/dnsaddr/loop.example.com/p2p/" + testPeerA}}
+	if _, err := ResolveBootstrapPeers(context.Background(), loop, []string{"/dnsaddr/loop.example.com/p2p/" + testPeerA}); err == nil {
+		t.Fatal("expected an error for a /dnsaddr/ loop")
+	}
+}
+
+func TestResolveBootstrapFallback(t *testing.T) {
+	cfg := &Config{}
+	cfg.Swarm.SwarmKey = DefaultTestnetSwarmKey
+	cfg.DNS.Resolvers = map[string]string{"example.com.": "https://127.0.0.1:1/dns-query"}
+	cfg.Bootstrap = []string{
+		"/dnsaddr/bootstrap.example.com",
+		"/dnsaddr/bootstrap.example.com/p2p/QmcZf59bWwK5XFi76CZX8cbJ4BhTzzA3gU1ZjYZcYW3dwt",
+		"/ip4/9.9.9.9/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN",
+	}
+	peers, err := cfg.ResolveBootstrapPeers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := ParseBootstrapPeers(DefaultTestnetBootstrapAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != len(defaults)+1 {
+		t.Fatalf("expected the custom peer and the testnet defaults, got %d peers", len(peers))
+	}
+
+	cfg.DNS.Resolvers = map[string]string{".": "udp://1.1.1.1"}
+	if _, err := cfg.ResolveBootstrapPeers(context.Background()); err == nil {
+		t.Fatal("expected an error for a non-DoH resolver")
+	}
+}
+
+func TestDNSAddrBootstrapEntry(t *testing.T) {
+	const domain = "/dnsaddr/bootstrap.example.com"
+	if err := ValidateBootstrap([]string{domain}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBootstrap([]string{"/dns4/bootstrap.example.com/tcp/4001"}); err == nil {
+		t.Fatal("expected an error for a /dns4/ address without a peer ID")
+	}
+
+	cfg := &Config{Bootstrap: []string{"/ip4/9.9.9.9/tcp/4001/p2p/" + testPeerB}}
+	if err := cfg.AddBootstrap(domain, domain); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Bootstrap = append(cfg.Bootstrap, domain)
+	if n, err := cfg.DedupeBootstrap(); err != nil || n != 1 {
+		t.Fatalf("expected one duplicate removed, got %d, %v", n, err)
+	}
+	if len(cfg.Bootstrap) != 2 || cfg.Bootstrap[1] != domain {
+		t.Fatalf("unexpected bootstrap list %v", cfg.Bootstrap)
+	}
+	peers, err := cfg.BootstrapPeers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].ID.String() != testPeerB {
+		t.Fatalf("expected the /dnsaddr/ domain to be skipped, got %v", peers)
+	}
+	if removed, err := cfg.RemoveBootstrap(domain); err != nil || len(removed) != 1 {
+		t.Fatalf("expected the domain to be removed, got %v, %v", removed, err)
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var q dnsmessage.Message
+		if err := q.Unpack(body); err != nil || len(q.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: q.ID, Response: true},
+			Questions: q.Questions,
+			Answers: []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Questions[0].Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET},
+				Body:   &dnsmessage.TXTResource{TXT: []string{"dnsaddr=/ip4/1.2.3.4/tcp/4001/p2p/", testPeerA}},
+			}},
+		}
+		b, _ := resp.Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	r := &dnsResolver{resolvers: map[string]TXTResolver{
+		"example.com.": &dohResolver{url: srv.URL, client: srv.Client()},
+		".":            stubTXTResolver{},
+	}}
+	txts, err := r.LookupTXT(context.Background(), "_dnsaddr.bootstrap.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txts) != 1 || txts[0] != "dnsaddr=/ip4/1.2.3.4/tcp/4001/p2p/"+testPeerA {
+		t.Fatalf("unexpected records %q", txts)
+	}
+	if _, err := r.LookupTXT(context.Background(), "_dnsaddr.example.org"); err == nil {
+		t.Fatal("expected names outside example.com to use the root resolver")
+	}
+}
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
 const DefaultInlineDNSLink = false
 
 type GatewaySpec struct {
@@ -34,6 +40,13 @@ type Gateway struct {
 	// gateway.
 	HTTPHeaders map[string][]string // HTTP headers to return with the gateway
 
+	// CORS configures the Access-Control-* headers of the gateway. It
+	// replaces the corresponding entries of HTTPHeaders.
+	CORS *CORS `json:",omitempty"`
+
+	// TLS enables HTTPS on the gateway listener when set.
+	TLS *TLS `json:",omitempty"`
+
 	// RootRedirect is the path to which requests to `/` on this gateway
 	// should be redirected.
 	RootRedirect string
@@ -60,3 +73,199 @@ type Gateway struct {
 	// Each key is a fully qualified domain name (FQDN).
 	PublicGateways map[string]*GatewaySpec
 }
+
+// DefaultGatewayPaths are the path prefixes handled by the gateway for hosts
+// that are not listed in Gateway.PublicGateways.
+var DefaultGatewayPaths = []string{"/btfs", "/btns"}
+
+// gatewayNamespaces are the content namespaces served by the gateway, both as
+// path prefixes (/btfs/$id) and as subdomains ($id.btfs.$gateway).
+var gatewayNamespaces = []string{"btfs", "btns"}
+
+// Headers returns the HTTP headers to return with the gateway, combining
+// HTTPHeaders and the CORS policy.
+func (g *Gateway) Headers() (map[string][]string, error) {
+	return mergeCORSHeaders("Gateway", g.HTTPHeaders, g.CORS)
+}
+
+// Validate checks the HTTP headers and the CORS policy of the gateway, that
+// every entry of PublicGateways is well-formed and that no combination of
+// entries breaks origin isolation.
+func (g *Gateway) Validate() error {
+	if err := validateCORSConfig("Gateway", g.HTTPHeaders, g.CORS); err != nil {
+		return err
+	}
+	for host, spec := range g.PublicGateways {
+		if err := validateGatewayHostname(host); err != nil {
+			return fmt.Errorf("Gateway.PublicGateways: %s", err)
+		}
+		if spec == nil {
+			// nil disables the default behavior for the host
+			continue
+		}
+		if err := spec.validate(); err != nil {
+			return fmt.Errorf("Gateway.PublicGateways[%q]: %s", host, err)
+		}
+	}
+
+	// A path gateway that lives under the namespace subdomain of a subdomain
+	// gateway ($foo.btfs.$gateway) would serve arbitrary content from the
+	// origin reserved for the $foo content root.
+	for host, spec := range g.PublicGateways {
+		if spec == nil || spec.UseSubdomains || !spec.servesContentPaths() {
+			continue
+		}
+		if parent, ok := g.subdomainGatewayFor(host); ok {
+			return fmt.Errorf("Gateway.PublicGateways[%q]: path gateway overlaps the subdomain namespace of %q and breaks origin isolation", host, parent)
+		}
+	}
+	return nil
+}
+
+func (gs *GatewaySpec) validate() error {
+	for _, p := range gs.Paths {
+		if err := validateGatewayPath(p); err != nil {
+			return err
+		}
+		if gs.UseSubdomains {
+			if ns, ok := gatewayNamespaceOf(p); ok && p != "/"+ns {
+				return fmt.Errorf("path %q cannot be served by a subdomain gateway, only %q is redirected to subdomains", p, "/"+ns)
+			}
+		}
+	}
+	if gs.InlineDNSLink == True && !gs.UseSubdomains {
+		return fmt.Errorf("InlineDNSLink requires UseSubdomains")
+	}
+	return nil
+}
+
+func (gs *GatewaySpec) servesContentPaths() bool {
+	for _, p := range gs.Paths {
+		if _, ok := gatewayNamespaceOf(p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GatewaySpecForHost returns the effective GatewaySpec for the given value of
+// the Host HTTP header. Hosts of the form $id.btfs.$gateway resolve to the
+// spec of $gateway when it uses subdomains.
+//
+// When the host does not match any entry in PublicGateways, the spec of a
+// plain path gateway honoring Gateway.NoDNSLink is returned, and known is
+// false.
+func (g *Gateway) GatewaySpecForHost(host string) (spec GatewaySpec, known bool) {
+	host = normalizeGatewayHost(host)
+
+	gs := g.lookupPublicGateway(host)
+	if gs == nil {
+		if parent, ok := g.subdomainGatewayFor(host); ok {
+			gs = g.lookupPublicGateway(parent)
+		}
+	}
+	if gs == nil {
+		return GatewaySpec{
+			Paths:         append([]string{}, DefaultGatewayPaths...),
+			NoDNSLink:     g.NoDNSLink,
+			InlineDNSLink: False,
+		}, false
+	}
+
+	spec = *gs
+	spec.Paths = append([]string{}, gs.Paths...)
+	if spec.InlineDNSLink == Default {
+		if DefaultInlineDNSLink {
+			spec.InlineDNSLink = True
+		} else {
+			spec.InlineDNSLink = False
+		}
+	}
+	return spec, true
+}
+
+// lookupPublicGateway returns the spec for host, trying an exact match first
+// and a single-label wildcard match (*.example.com) second.
+func (g *Gateway) lookupPublicGateway(host string) *GatewaySpec {
+	if gs, ok := g.PublicGateways[host]; ok {
+		return gs
+	}
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		if gs, ok := g.PublicGateways["*"+host[i:]]; ok {
+			return gs
+		}
+	}
+	return nil
+}
+
+// subdomainGatewayFor returns the subdomain gateway whose namespace contains
+// host, if any.
+func (g *Gateway) subdomainGatewayFor(host string) (string, bool) {
+	labels := strings.Split(host, ".")
+	for i := 1; i < len(labels)-1; i++ {
+		if _, ok := gatewayNamespaceOf("/" + labels[i]); !ok {
+			continue
+		}
+		parent := strings.Join(labels[i+1:], ".")
+		if gs := g.lookupPublicGateway(parent); gs != nil && gs.UseSubdomains {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+func normalizeGatewayHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// gatewayNamespaceOf returns the content namespace of the given path prefix.
+func gatewayNamespaceOf(p string) (string, bool) {
+	for _, ns := range gatewayNamespaces {
+		if p == "/"+ns || strings.HasPrefix(p, "/"+ns+"/") {
+			return ns, true
+		}
+	}
+	return "", false
+}
+
+func validateGatewayHostname(host string) error {
+	name := host
+	if strings.HasPrefix(name, "*.") {
+		name = name[2:]
+	}
+	if len(name) == 0 || len(name) > 253 {
+		return fmt.Errorf("invalid hostname %q", host)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("invalid hostname %q", host)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return fmt.Errorf("invalid hostname %q: must be a lower-case FQDN or a *.FQDN wildcard", host)
+			}
+		}
+	}
+	return nil
+}
+
+func validateGatewayPath(p string) error {
+	if !strings.HasPrefix(p, "/") {
+		return fmt.Errorf("invalid path prefix %q: must start with a slash", p)
+	}
+	if p == "/" || strings.HasSuffix(p, "/") {
+		return fmt.Errorf("invalid path prefix %q: must not end with a slash", p)
+	}
+	if strings.ContainsAny(p, "?#% \t") {
+		return fmt.Errorf("invalid path prefix %q", p)
+	}
+	for _, seg := range strings.Split(p[1:], "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("invalid path prefix %q", p)
+		}
+	}
+	return nil
+}
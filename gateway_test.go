@@ -0,0 +1,112 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestGatewayValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		gateways map[string]*GatewaySpec
+		valid    bool
+	}{
+		"path gateway": {
+			gateways: map[string]*GatewaySpec{"gw.example.com": {Paths: []string{"/btfs", "/btns"}}},
+			valid:    true,
+		},
+		"subdomain gateway": {
+			gateways: map[string]*GatewaySpec{"example.com": {Paths: []string{"/btfs", "/api"}, UseSubdomains: true, InlineDNSLink: True}},
+			valid:    true,
+		},
+		"wildcard": {
+			gateways: map[string]*GatewaySpec{"*.example.com": {Paths: []string{"/btfs"}}},
+			valid:    true,
+		},
+		"disabled entry": {
+			gateways: map[string]*GatewaySpec{"localhost": nil},
+			valid:    true,
+		},
+		"invalid hostname": {
+			gateways: map[string]*GatewaySpec{"gw_example.com": {Paths: []string{"/btfs"}}},
+		},
+		"upper-case hostname": {
+			gateways: map[string]*GatewaySpec{"Example.com": {Paths: []string{"/btfs"}}},
+		},
+		"invalid path": {
+			gateways: map[string]*GatewaySpec{"example.com": {Paths: []string{"btfs/"}}},
+		},
+		"content path on subdomain gateway": {
+			gateways: map[string]*GatewaySpec{"example.com": {Paths: []string{"/btfs/QmFoo"}, UseSubdomains: true}},
+		},
+		"InlineDNSLink without subdomains": {
+			gateways: map[string]*GatewaySpec{"example.com": {Paths: []string{"/btfs"}, InlineDNSLink: True}},
+		},
+		"path gateway in subdomain namespace": {
+			gateways: map[string]*GatewaySpec{
+				"example.com":          {Paths: []string{"/btfs"}, UseSubdomains: true},
+				"foo.btfs.example.com": {Paths: []string{"/btfs"}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			g := Gateway{PublicGateways: tc.gateways}
+			err := g.Validate()
+			if tc.valid && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestGatewaySpecForHost(t *testing.T) {
+	g := Gateway{
+		NoDNSLink: true,
+		PublicGateways: map[string]*GatewaySpec{
+			"example.com":    {Paths: []string{"/btfs"}, UseSubdomains: true},
+			"*.example.net":  {Paths: []string{"/btns"}},
+			"gw.example.org": {Paths: []string{"/btfs"}, NoDNSLink: false},
+			"localhost":      nil,
+		},
+	}
+
+	for host, want := range map[string]string{
+		"example.com":              "example.com",
+		"EXAMPLE.com.":             "example.com",
+		"example.com:8080":         "example.com",
+		"bafyfoo.btfs.example.com": "example.com",
+		"foo.example.net":          "*.example.net",
+		"gw.example.org":           "gw.example.org",
+	} {
+		spec, known := g.GatewaySpecForHost(host)
+		if !known {
+			t.Fatalf("expected %q to be a known gateway", host)
+		}
+		if spec.Paths[0] != g.PublicGateways[want].Paths[0] {
+			t.Fatalf("expected %q to resolve to %q, got %v", host, want, spec)
+		}
+		if spec.InlineDNSLink == Default {
+			t.Fatal("expected InlineDNSLink to be resolved")
+		}
+	}
+
+	spec, _ := g.GatewaySpecForHost("gw.example.org")
+	if spec.NoDNSLink {
+		t.Fatal("expected the per-host NoDNSLink to override Gateway.NoDNSLink")
+	}
+	spec.Paths[0] = "/changed"
+	if g.PublicGateways["gw.example.org"].Paths[0] != "/btfs" {
+		t.Fatal("expected the returned spec to be a copy")
+	}
+
+	for _, host := range []string{"localhost", "unknown.example.org", "foo.bar.example.net"} {
+		spec, known := g.GatewaySpecForHost(host)
+		if known {
+			t.Fatalf("expected %q to be unknown", host)
+		}
+		if !spec.NoDNSLink {
+			t.Fatal("expected Gateway.NoDNSLink to apply to unknown hosts")
+		}
+	}
+}
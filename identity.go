@@ -1,9 +1,20 @@
 package config
 
 import (
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	ic "github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/crypto/sha3"
 )
 
 const IdentityTag = "Identity"
@@ -12,20 +23,41 @@ const MnemonicTag = "Mnemonic"
 const PrivKeySelector = IdentityTag + "." + PrivKeyTag
 const MnemonicSelector = IdentityTag + "." + MnemonicTag
 
+// ErrNotSecp256k1 is returned when a BTTC wallet is derived from a key that
+// is not a secp256k1 key.
+var ErrNotSecp256k1 = errors.New("BTTC wallet requires a secp256k1 key")
+
 // Identity tracks the configuration of the local node's identity.
 type Identity struct {
-	PeerID            string
-	PrivKey           string `json:",omitempty"`
+	PeerID  string
+	PrivKey string `json:",omitempty"`
+
+	// PrivKeyFile, PrivKeyEnv and PrivKeyCommand load the private key,
+	// encoded like PrivKey, from a file, an environment variable or the
+	// standard output of a command instead of the config. At most one
+	// private key source may be set. PrivKeyFile must be an absolute path.
+	PrivKeyFile    string   `json:",omitempty"`
+	PrivKeyEnv     string   `json:",omitempty"`
+	PrivKeyCommand []string `json:",omitempty"`
+
 	HexPrivKey        string `json:",omitempty"`
 	BttcAddr          string `json:",omitempty"`
 	Mnemonic          string `json:",omitempty"`
 	EncryptedMnemonic string `json:",omitempty"`
 	EncryptedPrivKey  string `json:",omitempty"`
+
+	// RetiredKeys are the previous node keys, oldest first.
+	RetiredKeys []RetiredKey `json:",omitempty"`
 }
 
-// DecodePrivateKey is a helper to decode the users PrivateKey
+// DecodePrivateKey is a helper to decode the users PrivateKey, loading it
+// from PrivKeyFile, PrivKeyEnv or PrivKeyCommand if one of them is set.
 func (i *Identity) DecodePrivateKey(passphrase string) (ic.PrivKey, error) {
-	pkb, err := base64.StdEncoding.DecodeString(i.PrivKey)
+	encoded, err := i.privKeyString()
+	if err != nil {
+		return nil, err
+	}
+	pkb, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return nil, err
 	}
@@ -34,3 +66,304 @@ func (i *Identity) DecodePrivateKey(passphrase string) (ic.PrivKey, error) {
 	// TODO(security)
 	return ic.UnmarshalPrivateKey(pkb)
 }
+
+// Verify checks that PeerID, the private key and the BTTC wallet fields
+// describe the same key, that RSA keys are at least MinRsaKeyBits long, and
+// returns the key type.
+//
+// When the key is only stored encrypted, PeerID is checked against the public
+// key embedded in it instead. RSA peer IDs do not embed their key, so they
+// can only be checked to be well-formed.
+func (i *Identity) Verify() (pb.KeyType, error) {
+	id, err := peer.Decode(i.PeerID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Identity.PeerID %q: %s", i.PeerID, err)
+	}
+	if i.hasExternalPrivKey() && (i.HexPrivKey != "" || i.Mnemonic != "") {
+		return 0, fmt.Errorf("Identity.HexPrivKey and Identity.Mnemonic must not be set when the private key is loaded from a file, an environment variable or a command")
+	}
+
+	if i.PrivKey == "" && !i.hasExternalPrivKey() {
+		if i.EncryptedPrivKey == "" {
+			return 0, fmt.Errorf("Identity has no private key")
+		}
+		pk, err := id.ExtractPublicKey()
+		if errors.Is(err, peer.ErrNoPublicKey) {
+			return pb.KeyType_RSA, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid Identity.PeerID %q: %s", i.PeerID, err)
+		}
+		if i.BttcAddr != "" {
+			addr, err := BttcAddrFromPubKey(pk)
+			if err != nil {
+				return 0, err
+			}
+			if !strings.EqualFold(i.BttcAddr, addr) {
+				return 0, fmt.Errorf("Identity.BttcAddr %s does not match Identity.PeerID, expected %s", i.BttcAddr, addr)
+			}
+		}
+		return pk.Type(), nil
+	}
+
+	sk, err := i.DecodePrivateKey("")
+	if err != nil {
+		return 0, fmt.Errorf("invalid Identity.PrivKey: %s", err)
+	}
+	if sk.Type() == pb.KeyType_RSA {
+		raw, err := sk.GetPublic().Raw()
+		if err != nil {
+			return 0, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(raw)
+		if err != nil {
+			return 0, err
+		}
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return 0, fmt.Errorf("invalid Identity.PrivKey: not an RSA key")
+		}
+		if bits := rsaPub.N.BitLen(); bits < ic.MinRsaKeyBits {
+			return 0, fmt.Errorf("%w: Identity.PrivKey is %d bits", ic.ErrRsaKeyTooSmall, bits)
+		}
+	}
+	derived, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return 0, err
+	}
+	if derived != id {
+		return 0, fmt.Errorf("Identity.PeerID %s does not match Identity.PrivKey, expected %s", id, derived)
+	}
+	if err := i.verifyBttcAddr(sk); err != nil {
+		return 0, err
+	}
+	return sk.Type(), nil
+}
+
+// SetBttcWallet derives HexPrivKey and BttcAddr from the node key. When the
+// key is loaded from PrivKeyFile, PrivKeyEnv or PrivKeyCommand, only BttcAddr
+// is set, so that the key stays out of the config.
+func (i *Identity) SetBttcWallet() error {
+	sk, err := i.DecodePrivateKey("")
+	if err != nil {
+		return err
+	}
+	hexKey, err := HexPrivKeyFromPrivKey(sk)
+	if err != nil {
+		return err
+	}
+	addr, err := BttcAddrFromPubKey(sk.GetPublic())
+	if err != nil {
+		return err
+	}
+	if !i.hasExternalPrivKey() {
+		i.HexPrivKey = hexKey
+	}
+	i.BttcAddr = addr
+	return nil
+}
+
+// VerifyBttcAddr checks that HexPrivKey and BttcAddr, when set, belong to the
+// node key.
+func (i *Identity) VerifyBttcAddr() error {
+	if i.HexPrivKey == "" && i.BttcAddr == "" {
+		return nil
+	}
+	sk, err := i.DecodePrivateKey("")
+	if err != nil {
+		return err
+	}
+	return i.verifyBttcAddr(sk)
+}
+
+// verifyBttcAddr checks HexPrivKey and BttcAddr against the decoded node key.
+func (i *Identity) verifyBttcAddr(sk ic.PrivKey) error {
+	if i.HexPrivKey != "" {
+		hexKey, err := HexPrivKeyFromPrivKey(sk)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(strings.TrimPrefix(i.HexPrivKey, "0x"), hexKey) {
+			return fmt.Errorf("Identity.HexPrivKey does not match Identity.PrivKey")
+		}
+	}
+	if i.BttcAddr != "" {
+		addr, err := BttcAddrFromPubKey(sk.GetPublic())
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(i.BttcAddr, addr) {
+			return fmt.Errorf("Identity.BttcAddr %s does not match Identity.PrivKey, expected %s", i.BttcAddr, addr)
+		}
+	}
+	return nil
+}
+
+// HexPrivKeyFromPrivKey returns the hex-encoded raw secp256k1 private key,
+// as used by wallets and by the importKey parameter of IdentityConfig.
+func HexPrivKeyFromPrivKey(sk ic.PrivKey) (string, error) {
+	if sk.Type() != pb.KeyType_Secp256k1 {
+		return "", fmt.Errorf("%w, got %s", ErrNotSecp256k1, sk.Type())
+	}
+	raw, err := sk.Raw()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// BttcAddrFromPubKey returns the EIP-55 checksummed BTTC (EVM) address of a
+// secp256k1 public key.
+func BttcAddrFromPubKey(pk ic.PubKey) (string, error) {
+	if pk.Type() != pb.KeyType_Secp256k1 {
+		return "", fmt.Errorf("%w, got %s", ErrNotSecp256k1, pk.Type())
+	}
+	raw, err := pk.Raw()
+	if err != nil {
+		return "", err
+	}
+	key, err := secp256k1.ParsePubKey(raw)
+	if err != nil {
+		return "", err
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(key.SerializeUncompressed()[1:])
+	return ChecksumHexAddress(h.Sum(nil)[12:]), nil
+}
+
+// RetiredKey is a node key that was replaced by RotateKey. It is kept so the
+// node can still sign handover records for its previous identity.
+type RetiredKey struct {
+	PeerID            string
+	PrivKey           string `json:",omitempty"`
+	EncryptedPrivKey  string `json:",omitempty"`
+	BttcAddr          string `json:",omitempty"`
+	Mnemonic          string `json:",omitempty"`
+	EncryptedMnemonic string `json:",omitempty"`
+	RetiredAt         time.Time
+}
+
+// DecodePrivateKey decodes the retired private key.
+func (rk *RetiredKey) DecodePrivateKey(passphrase string) (ic.PrivKey, error) {
+	if rk.PrivKey == "" {
+		return nil, fmt.Errorf("retired key %s is only stored encrypted", rk.PeerID)
+	}
+	pkb, err := base64.StdEncoding.DecodeString(rk.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO(security)
+	return ic.UnmarshalPrivateKey(pkb)
+}
+
+// RotateKeyOptions configures RotateKey.
+type RotateKeyOptions struct {
+	// KeyType is the type of the new key: "RSA", "Ed25519", "Secp256k1" or
+	// "ECDSA". Secp256k1 is used if empty.
+	KeyType string
+	// Bits is the size of the new key, used for RSA keys only.
+	Bits int
+	// Encrypt encrypts the new key. It is required when the current key is
+	// only stored encrypted, and the result is stored in EncryptedPrivKey.
+	Encrypt func(ic.PrivKey) (string, error)
+}
+
+// RotateKey replaces the node key with a newly generated one. The previous
+// key is appended to RetiredKeys, and PeerID and the BTTC wallet fields are
+// updated for the new key. The mnemonic belongs to the previous key, so it
+// is retired along with it.
+func (i *Identity) RotateKey(opts RotateKeyOptions) (ic.PrivKey, error) {
+	if i.hasExternalPrivKey() {
+		return nil, fmt.Errorf("identity key is loaded from an external source and cannot be rotated in the config")
+	}
+	encrypted := i.PrivKey == "" && i.EncryptedPrivKey != ""
+	if i.PrivKey == "" && !encrypted {
+		return nil, fmt.Errorf("identity has no private key to rotate")
+	}
+	if encrypted && opts.Encrypt == nil {
+		return nil, fmt.Errorf("identity key is stored encrypted, an Encrypt function is required")
+	}
+
+	keyType, _, err := parseKeyType(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	bits := opts.Bits
+	if bits == 0 {
+		bits = 2048
+	}
+	if keyType == ic.RSA && bits < ic.MinRsaKeyBits {
+		return nil, ic.ErrRsaKeyTooSmall
+	}
+	sk, pk, err := ic.GenerateKeyPair(keyType, bits)
+	if err != nil {
+		return nil, err
+	}
+	id, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	next := Identity{PeerID: id.String(), RetiredKeys: i.RetiredKeys}
+	if encrypted {
+		if next.EncryptedPrivKey, err = opts.Encrypt(sk); err != nil {
+			return nil, err
+		}
+	} else {
+		skbytes, err := ic.MarshalPrivateKey(sk)
+		if err != nil {
+			return nil, err
+		}
+		next.PrivKey = base64.StdEncoding.EncodeToString(skbytes)
+	}
+	if sk.Type() == pb.KeyType_Secp256k1 {
+		if !encrypted {
+			if next.HexPrivKey, err = HexPrivKeyFromPrivKey(sk); err != nil {
+				return nil, err
+			}
+		}
+		if next.BttcAddr, err = BttcAddrFromPubKey(pk); err != nil {
+			return nil, err
+		}
+	}
+
+	next.RetiredKeys = append(next.RetiredKeys, RetiredKey{
+		PeerID:            i.PeerID,
+		PrivKey:           i.PrivKey,
+		EncryptedPrivKey:  i.EncryptedPrivKey,
+		BttcAddr:          i.BttcAddr,
+		Mnemonic:          i.Mnemonic,
+		EncryptedMnemonic: i.EncryptedMnemonic,
+		RetiredAt:         time.Now().UTC(),
+	})
+	*i = next
+	return sk, nil
+}
+
+// RetiredKey returns the retired key with the given peer ID.
+func (i *Identity) RetiredKey(peerID string) (*RetiredKey, bool) {
+	for k := range i.RetiredKeys {
+		if i.RetiredKeys[k].PeerID == peerID {
+			return &i.RetiredKeys[k], true
+		}
+	}
+	return nil, false
+}
+
+// parseKeyType maps a key type name to its libp2p key type, defaulting to
+// Secp256k1 when name is empty.
+func parseKeyType(name string) (int, string, error) {
+	switch name {
+	case "RSA":
+		return ic.RSA, name, nil
+	case "Ed25519":
+		return ic.Ed25519, name, nil
+	case "Secp256k1", "":
+		return ic.Secp256k1, "Secp256k1", nil
+	case "ECDSA":
+		return ic.ECDSA, name, nil
+	default:
+		return 0, "", fmt.Errorf("unknown key type %q", name)
+	}
+}
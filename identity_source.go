@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// PrivKeyCommandTimeout bounds how long Identity.PrivKeyCommand may run.
+const PrivKeyCommandTimeout = 10 * time.Second
+
+// hasExternalPrivKey reports whether the private key is loaded from a file,
+// an environment variable or a command instead of the config.
+func (i *Identity) hasExternalPrivKey() bool {
+	return i.PrivKeyFile != "" || i.PrivKeyEnv != "" || len(i.PrivKeyCommand) > 0
+}
+
+// privKeyString returns the base64-encoded private key from whichever source
+// is configured. At most one of PrivKey, PrivKeyFile, PrivKeyEnv and
+// PrivKeyCommand may be set.
+func (i *Identity) privKeyString() (string, error) {
+	var sources []string
+	if i.PrivKey != "" {
+		sources = append(sources, "PrivKey")
+	}
+	if i.PrivKeyFile != "" {
+		sources = append(sources, "PrivKeyFile")
+	}
+	if i.PrivKeyEnv != "" {
+		sources = append(sources, "PrivKeyEnv")
+	}
+	if len(i.PrivKeyCommand) > 0 {
+		sources = append(sources, "PrivKeyCommand")
+	}
+	if len(sources) > 1 {
+		return "", fmt.Errorf("only one private key source may be set, found Identity.%s", strings.Join(sources, ", Identity."))
+	}
+
+	switch {
+	case i.PrivKeyFile != "":
+		return readPrivKeyFile(i.PrivKeyFile)
+	case i.PrivKeyEnv != "":
+		v, ok := os.LookupEnv(i.PrivKeyEnv)
+		if !ok || strings.TrimSpace(v) == "" {
+			return "", fmt.Errorf("Identity.PrivKeyEnv: environment variable %s is not set", i.PrivKeyEnv)
+		}
+		return strings.TrimSpace(v), nil
+	case len(i.PrivKeyCommand) > 0:
+		return runPrivKeyCommand(i.PrivKeyCommand)
+	}
+	return i.PrivKey, nil
+}
+
+// readPrivKeyFile reads a private key file. The path must be absolute, as
+// the identity does not know which repo it was loaded from. The file must
+// not be accessible by group or others, except on Windows where permission
+// bits are not meaningful.
+func readPrivKeyFile(file string) (string, error) {
+	if !filepath.IsAbs(file) {
+		return "", fmt.Errorf("Identity.PrivKeyFile: %s is not an absolute path", file)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("Identity.PrivKeyFile: %s", err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("Identity.PrivKeyFile: %s has permissions %04o, must not be accessible by group or others", file, fi.Mode().Perm())
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("Identity.PrivKeyFile: %s", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// runPrivKeyCommand runs the private key helper command and returns its
+// standard output.
+func runPrivKeyCommand(command []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), PrivKeyCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("Identity.PrivKeyCommand: timed out after %s", PrivKeyCommandTimeout)
+		}
+		return "", fmt.Errorf("Identity.PrivKeyCommand: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	out := strings.TrimSpace(stdout.String())
+	if out == "" {
+		return "", fmt.Errorf("Identity.PrivKeyCommand: no key on standard output")
+	}
+	return out, nil
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func TestBttcWallet(t *testing.T) {
+	const hexKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	const addr = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+	ident, err := IdentityConfig(io.Discard, 2048, "", hexKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ident.HexPrivKey != hexKey {
+		t.Fatalf("expected hex key %s, got %s", hexKey, ident.HexPrivKey)
+	}
+	if ident.BttcAddr != addr {
+		t.Fatalf("expected address %s, got %s", addr, ident.BttcAddr)
+	}
+	if err := ident.VerifyBttcAddr(); err != nil {
+		t.Fatal(err)
+	}
+
+	ident.BttcAddr = "0x0000000000000000000000000000000000000000"
+	if err := ident.VerifyBttcAddr(); err == nil {
+		t.Fatal("expected a mismatched address to be rejected")
+	}
+
+	ed, err := IdentityConfig(io.Discard, 2048, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ed.HexPrivKey != "" || ed.BttcAddr != "" {
+		t.Fatal("expected no wallet for an Ed25519 identity")
+	}
+	if err := ed.SetBttcWallet(); !errors.Is(err, ErrNotSecp256k1) {
+		t.Fatalf("expected ErrNotSecp256k1, got %v", err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	ident, err := MnemonicIdentityConfig(io.Discard, testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := ident
+
+	sk, err := ident.RotateKey(RotateKeyOptions{KeyType: "Ed25519"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Type() != ic.Ed25519 || ident.PeerID == old.PeerID {
+		t.Fatalf("expected a new Ed25519 identity, got %+v", ident)
+	}
+	if ident.HexPrivKey != "" || ident.BttcAddr != "" || ident.Mnemonic != "" {
+		t.Fatal("expected wallet fields and mnemonic of the old key to be cleared")
+	}
+	decoded, err := ident.DecodePrivateKey("")
+	if err != nil || !decoded.Equals(sk) {
+		t.Fatalf("expected PrivKey to hold the new key: %v", err)
+	}
+
+	rk, ok := ident.RetiredKey(old.PeerID)
+	if !ok {
+		t.Fatal("expected the old key to be retired")
+	}
+	if rk.Mnemonic != testMnemonic || rk.BttcAddr != old.BttcAddr || rk.RetiredAt.IsZero() {
+		t.Fatalf("unexpected retired key %+v", rk)
+	}
+	oldSk, err := rk.DecodePrivateKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, _ := BttcAddrFromPubKey(oldSk.GetPublic()); addr != old.BttcAddr {
+		t.Fatal("expected the retired key to decode to the old key")
+	}
+
+	if _, err := ident.RotateKey(RotateKeyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ident.RetiredKeys) != 2 || ident.RetiredKeys[0].PeerID != old.PeerID {
+		t.Fatalf("expected two retired keys, got %d", len(ident.RetiredKeys))
+	}
+	if ident.BttcAddr == "" {
+		t.Fatal("expected a BTTC address for the new secp256k1 key")
+	}
+
+	if _, err := ident.RotateKey(RotateKeyOptions{KeyType: "DSA"}); err == nil {
+		t.Fatal("expected an error for an unknown key type")
+	}
+	for _, keyType := range []string{"DSA", "secp256k1"} {
+		fallback, err := IdentityConfig(io.Discard, 2048, keyType, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kt, err := fallback.Verify(); err != nil || kt != ic.Secp256k1 {
+			t.Fatalf("%s: expected a Secp256k1 identity, got %s, %v", keyType, kt, err)
+		}
+	}
+	if _, err := ident.RotateKey(RotateKeyOptions{KeyType: "RSA", Bits: 1024}); err == nil {
+		t.Fatal("expected an error for a small RSA key")
+	}
+}
+
+func TestRotateEncryptedKey(t *testing.T) {
+	ident := Identity{PeerID: "old", EncryptedPrivKey: "ciphertext"}
+	if _, err := ident.RotateKey(RotateKeyOptions{}); err == nil {
+		t.Fatal("expected an error without an Encrypt function")
+	}
+
+	encrypt := func(sk ic.PrivKey) (string, error) {
+		b, err := ic.MarshalPrivateKey(sk)
+		return "enc:" + base64.StdEncoding.EncodeToString(b), err
+	}
+	if _, err := ident.RotateKey(RotateKeyOptions{Encrypt: encrypt}); err != nil {
+		t.Fatal(err)
+	}
+	if ident.PrivKey != "" || ident.HexPrivKey != "" || ident.EncryptedPrivKey[:4] != "enc:" {
+		t.Fatalf("expected the new key to be stored encrypted only, got %+v", ident)
+	}
+	if ident.BttcAddr == "" {
+		t.Fatal("expected a BTTC address derived from the public key")
+	}
+	rk, ok := ident.RetiredKey("old")
+	if !ok || rk.EncryptedPrivKey != "ciphertext" {
+		t.Fatalf("expected the encrypted old key to be retired, got %+v", rk)
+	}
+	if _, err := rk.DecodePrivateKey(""); err == nil {
+		t.Fatal("expected an error decoding an encrypted-only retired key")
+	}
+}
+
+func TestIdentityVerify(t *testing.T) {
+	for _, keyType := range []string{"Secp256k1", "Ed25519", "RSA"} {
+		ident, err := IdentityConfig(io.Discard, 2048, keyType, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kt, err := ident.Verify()
+		if err != nil {
+			t.Fatalf("%s: %s", keyType, err)
+		}
+		if kt.String() != keyType {
+			t.Fatalf("expected key type %s, got %s", keyType, kt)
+		}
+	}
+
+	a, err := IdentityConfig(io.Discard, 2048, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := IdentityConfig(io.Discard, 2048, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mismatched := a
+	mismatched.PeerID = b.PeerID
+	if _, err := mismatched.Verify(); err == nil {
+		t.Fatal("expected an error for a PeerID of another key")
+	}
+	mismatched = a
+	mismatched.BttcAddr = b.BttcAddr
+	if _, err := mismatched.Verify(); err == nil {
+		t.Fatal("expected an error for a BttcAddr of another key")
+	}
+	mismatched = a
+	mismatched.PeerID = "not-a-peer-id"
+	if _, err := mismatched.Verify(); err == nil {
+		t.Fatal("expected an error for a malformed PeerID")
+	}
+
+	encrypted := Identity{PeerID: a.PeerID, BttcAddr: a.BttcAddr, EncryptedPrivKey: "ciphertext"}
+	if kt, err := encrypted.Verify(); err != nil || kt != ic.Secp256k1 {
+		t.Fatalf("expected an encrypted secp256k1 identity to verify, got %s, %v", kt, err)
+	}
+	encrypted.BttcAddr = b.BttcAddr
+	if _, err := encrypted.Verify(); err == nil {
+		t.Fatal("expected an error for a BttcAddr not matching the PeerID")
+	}
+	if _, err := (&Identity{PeerID: a.PeerID}).Verify(); err == nil {
+		t.Fatal("expected an error for an identity without a key")
+	}
+}
+
+func TestExternalPrivKey(t *testing.T) {
+	ident, err := IdentityConfig(io.Discard, 2048, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := ident.PrivKey
+	ident.PrivKey = ""
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "node.key")
+	if err := os.WriteFile(file, []byte(encoded+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BTFS_TEST_PRIVKEY", encoded)
+
+	sources := map[string]Identity{
+		"file": {PrivKeyFile: file},
+		"env":  {PrivKeyEnv: "BTFS_TEST_PRIVKEY"},
+	}
+	if runtime.GOOS != "windows" {
+		sources["command"] = Identity{PrivKeyCommand: []string{"cat", file}}
+	}
+	for name, src := range sources {
+		src.PeerID = ident.PeerID
+		src.BttcAddr = ident.BttcAddr
+		if _, err := src.Verify(); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if _, err := src.RotateKey(RotateKeyOptions{}); err == nil {
+			t.Fatalf("%s: expected rotation to be refused", name)
+		}
+	}
+
+	plaintext := Identity{PeerID: ident.PeerID, PrivKeyEnv: "BTFS_TEST_PRIVKEY", HexPrivKey: ident.HexPrivKey}
+	if _, err := plaintext.Verify(); err == nil {
+		t.Fatal("expected an error for HexPrivKey with an external key source")
+	}
+	plaintext = Identity{PeerID: ident.PeerID, PrivKeyEnv: "BTFS_TEST_PRIVKEY", Mnemonic: testMnemonic}
+	if _, err := plaintext.Verify(); err == nil {
+		t.Fatal("expected an error for Mnemonic with an external key source")
+	}
+	wallet := Identity{PeerID: ident.PeerID, PrivKeyEnv: "BTFS_TEST_PRIVKEY"}
+	if err := wallet.SetBttcWallet(); err != nil {
+		t.Fatal(err)
+	}
+	if wallet.HexPrivKey != "" || wallet.BttcAddr != ident.BttcAddr {
+		t.Fatalf("expected only BttcAddr to be set, got %+v", wallet)
+	}
+
+	both := Identity{PrivKey: encoded, PrivKeyEnv: "BTFS_TEST_PRIVKEY"}
+	if _, err := both.DecodePrivateKey(""); err == nil {
+		t.Fatal("expected an error for more than one key source")
+	}
+	if _, err := (&Identity{PrivKeyEnv: "BTFS_TEST_UNSET"}).DecodePrivateKey(""); err == nil {
+		t.Fatal("expected an error for an unset environment variable")
+	}
+	if _, err := (&Identity{PrivKeyFile: "node.key"}).DecodePrivateKey(""); err == nil {
+		t.Fatal("expected an error for a relative key file path")
+	}
+
+	if runtime.GOOS != "windows" {
+		runs := filepath.Join(dir, "runs")
+		counted := Identity{
+			PeerID:         ident.PeerID,
+			BttcAddr:       ident.BttcAddr,
+			PrivKeyCommand: []string{"sh", "-c", `echo >> "$0" && cat "$1"`, runs, file},
+		}
+		if _, err := counted.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(runs); len(b) != 1 {
+			t.Fatalf("expected Verify to run PrivKeyCommand once, ran %d times", len(b))
+		}
+
+		if err := os.Chmod(file, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&Identity{PrivKeyFile: file}).DecodePrivateKey(""); err == nil {
+			t.Fatal("expected an error for a world-readable key file")
+		}
+		if _, err := (&Identity{PrivKeyCommand: []string{"false"}}).DecodePrivateKey(""); err == nil {
+			t.Fatal("expected an error for a failing command")
+		}
+	}
+}
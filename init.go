@@ -66,12 +66,8 @@ func Init(out io.Writer, nBitsForKeypair int, keyType string, importKey string,
 			Writable:     false,
 			NoFetch:      false,
 			PathPrefixes: []string{},
-			HTTPHeaders: map[string][]string{
-				"Access-Control-Allow-Origin":  {"*"},
-				"Access-Control-Allow-Methods": {"GET"},
-				"Access-Control-Allow-Headers": {"X-Requested-With", "Range", "User-Agent"},
-			},
-			APICommands: []string{},
+			HTTPHeaders:  DefaultGatewayCORS().HTTPHeaders(),
+			APICommands:  []string{},
 		},
 		S3CompatibleAPI: DefaultS3CompatibleAPIConfig(),
 		Services:        DefaultServicesConfig(),
@@ -87,7 +83,9 @@ func Init(out io.Writer, nBitsForKeypair int, keyType string, importKey string,
 				GracePeriod: NewOptionalDuration(DefaultConnMgrGracePeriod),
 				Type:        NewOptionalString("basic"),
 			},
-			EnableAutoRelay: DefaultEnableAutoRelay,
+			RelayClient: RelayClient{
+				Enabled: True,
+			},
 		},
 		Experimental: Experiments{
 			Libp2pStreamMounting: true, // Enabled for remote api
@@ -99,7 +97,7 @@ func Init(out io.Writer, nBitsForKeypair int, keyType string, importKey string,
 			HostsSyncMode:        DefaultHostsSyncMode.String(),
 		},
 		ChainInfo: ChainInfo{
-			ChainId: bttcChainID,
+			ChainId: BttcChainID,
 		},
 	}
 
@@ -165,9 +163,9 @@ func addressesConfig() Addresses {
 // DefaultDatastoreConfig is an internal function exported to aid in testing.
 func DefaultDatastoreConfig() Datastore {
 	return Datastore{
-		StorageMax:         "10GB",
+		StorageMax:         DefaultStorageMax,
 		StorageGCWatermark: 90, // 90%
-		GCPeriod:           "1h",
+		GCPeriod:           NewOptionalDuration(DefaultGCPeriod),
 		BloomFilterSize:    0,
 		Spec:               flatfsSpec(),
 	}
@@ -217,9 +215,13 @@ func flatfsSpec() map[string]interface{} {
 
 func DefaultS3CompatibleAPIConfig() S3CompatibleAPI {
 	return S3CompatibleAPI{
-		Address:     "127.0.0.1:6001",
-		Enable:      false,
-		HTTPHeaders: nil,
+		Address:              "127.0.0.1:6001",
+		Enable:               false,
+		HTTPHeaders:          nil,
+		Region:               DefaultS3CompatibleAPIRegion,
+		MaxObjectSize:        DefaultS3CompatibleAPIMaxObjectSize,
+		MultipartMaxParts:    DefaultS3CompatibleAPIMultipartMaxParts,
+		MultipartMinPartSize: DefaultS3CompatibleAPIMultipartMinPartSize,
 	}
 }
 
@@ -287,21 +289,12 @@ func IdentityConfig(out io.Writer, nbits int, keyType string, importKey string,
 	var pk ci.PubKey
 	var err error
 	if importKey == "" {
-		var key int
-
-		switch keyType {
-		case "RSA":
-			key = ci.RSA
-		case "Ed25519":
-			key = ci.Ed25519
-		case "Secp256k1":
-			key = ci.Secp256k1
-		case "ECDSA":
-			key = ci.ECDSA
-		default:
-			key = ci.Secp256k1
-			keyType = "Secp256k1"
+		// unknown key types fall back to Secp256k1, unlike RotateKey.
+		key, name, perr := parseKeyType(keyType)
+		if perr != nil {
+			key, name = ci.Secp256k1, "Secp256k1"
 		}
+		keyType = name
 
 		fmt.Fprintf(out, "generating %v-bit %s keypair...", nbits, keyType)
 		sk, pk, err = ci.GenerateKeyPair(key, nbits)
@@ -331,6 +324,11 @@ func IdentityConfig(out io.Writer, nbits int, keyType string, importKey string,
 	}
 	ident.PrivKey = base64.StdEncoding.EncodeToString(skbytes)
 	ident.Mnemonic = mnemonic
+	if sk.Type() == ci.Secp256k1 {
+		if err := ident.SetBttcWallet(); err != nil {
+			return ident, err
+		}
+	}
 
 	id, err := peer.IDFromPublicKey(pk)
 	if err != nil {
@@ -345,3 +343,26 @@ func GetAddrOfConst(i int) *int64 {
 	var r = int64(i)
 	return &r
 }
+
+// MnemonicIdentityConfig initializes an identity whose secp256k1 node key is
+// derived from a BIP-39 mnemonic along DefaultMnemonicDerivationPath, so the
+// identity can be recovered from the mnemonic alone. A new 12-word mnemonic
+// is generated if mnemonic is empty.
+func MnemonicIdentityConfig(out io.Writer, mnemonic string) (Identity, error) {
+	if mnemonic == "" {
+		var err error
+		mnemonic, err = GenerateMnemonic(DefaultMnemonicEntropyBits)
+		if err != nil {
+			return Identity{}, err
+		}
+	}
+	sk, err := PrivKeyFromMnemonic(mnemonic, "", DefaultMnemonicDerivationPath)
+	if err != nil {
+		return Identity{}, err
+	}
+	raw, err := sk.Raw()
+	if err != nil {
+		return Identity{}, err
+	}
+	return IdentityConfig(out, ci.MinRsaKeyBits, "", hex.EncodeToString(raw), mnemonic)
+}
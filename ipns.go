@@ -1,8 +1,38 @@
 package config
 
+import (
+	"fmt"
+)
+
 type Ipns struct {
-	RepublishPeriod string
-	RecordLifetime  string
+	RepublishPeriod *OptionalDuration `json:",omitempty"`
+	RecordLifetime  *OptionalDuration `json:",omitempty"`
 
 	ResolveCacheSize int
 }
+
+// Validate checks that the republish period is within bounds and that
+// records outlive the period they are republished at.
+func (i *Ipns) Validate() error {
+	if err := i.validateRepublishPeriod(); err != nil {
+		return err
+	}
+	return i.validateRecordLifetime()
+}
+
+func (i *Ipns) validateRepublishPeriod() error {
+	republish := i.RepublishPeriod.WithDefault(DefaultIpnsRepublishPeriod)
+	if republish < MinIpnsRepublishPeriod || republish > MaxIpnsRepublishPeriod {
+		return fmt.Errorf("Ipns.RepublishPeriod must be between %s and %s, got %s", MinIpnsRepublishPeriod, MaxIpnsRepublishPeriod, republish)
+	}
+	return nil
+}
+
+func (i *Ipns) validateRecordLifetime() error {
+	republish := i.RepublishPeriod.WithDefault(DefaultIpnsRepublishPeriod)
+	lifetime := i.RecordLifetime.WithDefault(DefaultIpnsRecordLifetime)
+	if lifetime <= republish {
+		return fmt.Errorf("Ipns.RecordLifetime (%s) must be longer than Ipns.RepublishPeriod (%s)", lifetime, republish)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLegacyPeriods(t *testing.T) {
+	var cfg Config
+	legacy := `{
+		"Datastore": {"GCPeriod": "30m"},
+		"Ipns": {"RepublishPeriod": "", "RecordLifetime": "48h", "ResolveCacheSize": 128}
+	}`
+	if err := json.Unmarshal([]byte(legacy), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Datastore.GCPeriod.WithDefault(DefaultGCPeriod) != 30*time.Minute {
+		t.Fatalf("unexpected GCPeriod %s", cfg.Datastore.GCPeriod)
+	}
+	if !cfg.Ipns.RepublishPeriod.IsDefault() {
+		t.Fatal("expected an empty RepublishPeriod to decode as the default")
+	}
+	if cfg.Ipns.RecordLifetime.WithDefault(DefaultIpnsRecordLifetime) != 48*time.Hour {
+		t.Fatalf("unexpected RecordLifetime %s", cfg.Ipns.RecordLifetime)
+	}
+	if err := cfg.Ipns.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"Datastore": {"GCPeriod": "hourly"}}`), &cfg); err == nil {
+		t.Fatal("expected an invalid duration to fail decoding")
+	}
+}
+
+func TestIpnsValidate(t *testing.T) {
+	for _, ipns := range []Ipns{
+		{RepublishPeriod: NewOptionalDuration(time.Second)},
+		{RepublishPeriod: NewOptionalDuration(48 * time.Hour)},
+		{RecordLifetime: NewOptionalDuration(time.Hour)},
+		{RepublishPeriod: NewOptionalDuration(12 * time.Hour), RecordLifetime: NewOptionalDuration(12 * time.Hour)},
+	} {
+		if err := ipns.Validate(); err == nil {
+			t.Fatalf("expected an error for %s/%s", ipns.RepublishPeriod, ipns.RecordLifetime)
+		}
+	}
+}
+
+func TestMigratePeriods(t *testing.T) {
+	cfg := &Config{}
+	cfg.Datastore.GCPeriod = NewOptionalDuration(-time.Hour)
+	cfg.Ipns.RepublishPeriod = NewOptionalDuration(20 * time.Hour)
+	cfg.Ipns.RecordLifetime = NewOptionalDuration(time.Hour)
+
+	if !migrate_21_Periods(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if !cfg.Datastore.GCPeriod.IsDefault() || !cfg.Ipns.RecordLifetime.IsDefault() {
+		t.Fatal("expected invalid periods to be reset")
+	}
+	if cfg.Ipns.RepublishPeriod.WithDefault(0) != 20*time.Hour {
+		t.Fatal("expected RepublishPeriod to be kept once the default lifetime is longer")
+	}
+	if migrate_21_Periods(cfg) {
+		t.Fatal("expected the migration to be idempotent")
+	}
+
+	cfg = &Config{}
+	cfg.Datastore.GCPeriod = NewOptionalDuration(30 * time.Minute)
+	cfg.Datastore.StorageGCWatermark = 0
+	cfg.Ipns.RepublishPeriod = NewOptionalDuration(time.Second)
+	cfg.Ipns.RecordLifetime = NewOptionalDuration(48 * time.Hour)
+	if !migrate_21_Periods(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if cfg.Datastore.GCPeriod.WithDefault(0) != 30*time.Minute {
+		t.Fatal("expected a valid GCPeriod to be kept")
+	}
+	if !cfg.Ipns.RepublishPeriod.IsDefault() || cfg.Ipns.RecordLifetime.WithDefault(0) != 48*time.Hour {
+		t.Fatal("expected only the invalid RepublishPeriod to be reset")
+	}
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Scrypt parameters for EncryptIdentity. The standard parameters match those
+// of Ethereum wallets; the light ones trade security for speed on constrained
+// hosts.
+const (
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	LightScryptN    = 1 << 12
+	LightScryptP    = 6
+)
+
+const (
+	keystoreVersion = 3
+	keystoreCipher  = "aes-128-ctr"
+	keystoreScryptR = 8
+	keystoreDKLen   = 32
+)
+
+// ErrKeystorePassword is returned by DecryptIdentity when the MAC of the
+// keystore does not match, which is almost always a wrong password.
+var ErrKeystorePassword = errors.New("could not decrypt keystore: wrong password")
+
+// keystoreJSON is an Ethereum v3 keystore with an additional btfs section.
+// Wallets that only know the v3 format ignore the btfs section.
+type keystoreJSON struct {
+	Address string          `json:"address,omitempty"`
+	Crypto  keystoreCrypto  `json:"crypto"`
+	ID      string          `json:"id"`
+	Version int             `json:"version"`
+	Btfs    *keystoreBtfsV1 `json:"btfs,omitempty"`
+}
+
+type keystoreBtfsV1 struct {
+	PeerID string `json:"peerID"`
+	// KeyType is the libp2p key type. For secp256k1 keys the v3 ciphertext
+	// is the raw key, as expected by Ethereum wallets; for other keys it is
+	// the libp2p-marshaled key.
+	KeyType  string          `json:"keyType"`
+	Mnemonic *keystoreCrypto `json:"mnemonic,omitempty"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams keystoreCipherParams   `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+type keystoreCipherParams struct {
+	IV string `json:"iv"`
+}
+
+// EncryptIdentity exports the node key, PeerID, BttcAddr and Mnemonic of an
+// identity into a password-encrypted keystore. Secp256k1 keystores can be
+// imported by Ethereum wallets as the BTTC wallet.
+func EncryptIdentity(ident *Identity, password string, scryptN, scryptP int) ([]byte, error) {
+	sk, err := ident.DecodePrivateKey("")
+	if err != nil {
+		return nil, err
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := keystoreJSON{
+		Version: keystoreVersion,
+		Btfs:    &keystoreBtfsV1{PeerID: id.String(), KeyType: sk.Type().String()},
+	}
+	var plaintext []byte
+	if sk.Type() == pb.KeyType_Secp256k1 {
+		if plaintext, err = sk.Raw(); err != nil {
+			return nil, err
+		}
+		addr, err := BttcAddrFromPubKey(sk.GetPublic())
+		if err != nil {
+			return nil, err
+		}
+		ks.Address = strings.ToLower(strings.TrimPrefix(addr, "0x"))
+	} else if plaintext, err = ic.MarshalPrivateKey(sk); err != nil {
+		return nil, err
+	}
+
+	if ks.Crypto, err = encryptKeystoreData(plaintext, password, scryptN, scryptP); err != nil {
+		return nil, err
+	}
+	if ident.Mnemonic != "" {
+		mc, err := encryptKeystoreData([]byte(ident.Mnemonic), password, scryptN, scryptP)
+		if err != nil {
+			return nil, err
+		}
+		ks.Btfs.Mnemonic = &mc
+	}
+	if ks.ID, err = newKeystoreUUID(); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptIdentity imports a keystore written by EncryptIdentity or by an
+// Ethereum wallet. Keystores without a btfs section are taken to hold a raw
+// secp256k1 key. The stored PeerID and address are checked against the key.
+func DecryptIdentity(keyjson []byte, password string) (Identity, error) {
+	var ident Identity
+	var ks keystoreJSON
+	if err := json.Unmarshal(keyjson, &ks); err != nil {
+		return ident, fmt.Errorf("invalid keystore: %s", err)
+	}
+	if ks.Version != keystoreVersion {
+		return ident, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	plaintext, err := decryptKeystoreData(&ks.Crypto, password)
+	if err != nil {
+		return ident, err
+	}
+
+	keyType := pb.KeyType_Secp256k1.String()
+	if ks.Btfs != nil {
+		keyType = ks.Btfs.KeyType
+	}
+	var sk ic.PrivKey
+	if keyType == pb.KeyType_Secp256k1.String() {
+		sk, err = ic.UnmarshalSecp256k1PrivateKey(plaintext)
+	} else {
+		sk, err = ic.UnmarshalPrivateKey(plaintext)
+	}
+	if err != nil {
+		return ident, fmt.Errorf("invalid keystore key: %s", err)
+	}
+	if sk.Type().String() != keyType {
+		return ident, fmt.Errorf("keystore key is %s, expected %s", sk.Type(), keyType)
+	}
+
+	skbytes, err := ic.MarshalPrivateKey(sk)
+	if err != nil {
+		return ident, err
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return ident, err
+	}
+	ident.PeerID = id.String()
+	ident.PrivKey = base64.StdEncoding.EncodeToString(skbytes)
+	if ks.Btfs != nil && ks.Btfs.PeerID != ident.PeerID {
+		return ident, fmt.Errorf("keystore peer ID %s does not match its key, expected %s", ks.Btfs.PeerID, ident.PeerID)
+	}
+	if sk.Type() == pb.KeyType_Secp256k1 {
+		if err := ident.SetBttcWallet(); err != nil {
+			return ident, err
+		}
+		if ks.Address != "" && !strings.EqualFold(strings.TrimPrefix(ks.Address, "0x"), strings.TrimPrefix(ident.BttcAddr, "0x")) {
+			return ident, fmt.Errorf("keystore address %s does not match its key, expected %s", ks.Address, ident.BttcAddr)
+		}
+	}
+	if ks.Btfs != nil && ks.Btfs.Mnemonic != nil {
+		m, err := decryptKeystoreData(ks.Btfs.Mnemonic, password)
+		if err != nil {
+			return ident, err
+		}
+		ident.Mnemonic = string(m)
+	}
+	return ident, nil
+}
+
+func encryptKeystoreData(data []byte, password string, scryptN, scryptP int) (keystoreCrypto, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return keystoreCrypto{}, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return keystoreCrypto{}, err
+	}
+	derived, err := scrypt.Key([]byte(password), salt, scryptN, keystoreScryptR, scryptP, keystoreDKLen)
+	if err != nil {
+		return keystoreCrypto{}, err
+	}
+	ciphertext, err := aesCTRXOR(derived[:16], data, iv)
+	if err != nil {
+		return keystoreCrypto{}, err
+	}
+	return keystoreCrypto{
+		Cipher:       keystoreCipher,
+		CipherText:   hex.EncodeToString(ciphertext),
+		CipherParams: keystoreCipherParams{IV: hex.EncodeToString(iv)},
+		KDF:          "scrypt",
+		KDFParams: map[string]interface{}{
+			"n":     scryptN,
+			"r":     keystoreScryptR,
+			"p":     scryptP,
+			"dklen": keystoreDKLen,
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(keystoreMAC(derived, ciphertext)),
+	}, nil
+}
+
+func decryptKeystoreData(c *keystoreCrypto, password string) ([]byte, error) {
+	if c.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported keystore cipher %q", c.Cipher)
+	}
+	ciphertext, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore ciphertext: %s", err)
+	}
+	iv, err := hex.DecodeString(c.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore iv: %s", err)
+	}
+	mac, err := hex.DecodeString(c.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore mac: %s", err)
+	}
+	derived, err := keystoreKDF(c, password)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(keystoreMAC(derived, ciphertext), mac) {
+		return nil, ErrKeystorePassword
+	}
+	return aesCTRXOR(derived[:16], ciphertext, iv)
+}
+
+func keystoreKDF(c *keystoreCrypto, password string) ([]byte, error) {
+	param := func(name string) int {
+		v, _ := c.KDFParams[name].(float64)
+		return int(v)
+	}
+	saltHex, _ := c.KDFParams["salt"].(string)
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt: %s", err)
+	}
+	dklen := param("dklen")
+	if dklen < 32 {
+		return nil, fmt.Errorf("invalid keystore dklen %d", dklen)
+	}
+
+	switch c.KDF {
+	case "scrypt":
+		return scrypt.Key([]byte(password), salt, param("n"), param("r"), param("p"), dklen)
+	case "pbkdf2":
+		if prf, _ := c.KDFParams["prf"].(string); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported keystore pbkdf2 prf %q", prf)
+		}
+		return pbkdf2.Key([]byte(password), salt, param("c"), dklen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported keystore kdf %q", c.KDF)
+	}
+}
+
+func keystoreMAC(derived, ciphertext []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(derived[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+func aesCTRXOR(key, in, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+func newKeystoreUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	var b bytes.Buffer
+	for i, c := range u {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			b.WriteByte('-')
+		}
+		fmt.Fprintf(&b, "%02x", c)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecryptIdentityEthereumKeystore(t *testing.T) {
+	// Test vector from the Web3 Secret Storage definition.
+	const keyjson = `{
+		"crypto": {
+			"cipher": "aes-128-ctr",
+			"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+			"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+			"kdf": "scrypt",
+			"kdfparams": {"dklen": 32, "n": 262144, "p": 8, "r": 1, "salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},
+			"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+		},
+		"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		"version": 3
+	}`
+	ident, err := DecryptIdentity([]byte(keyjson), "testpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ident.HexPrivKey != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Fatalf("unexpected key %s", ident.HexPrivKey)
+	}
+	if _, err := ident.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptIdentity([]byte(keyjson), "wrong"); !errors.Is(err, ErrKeystorePassword) {
+		t.Fatalf("expected a password error, got %v", err)
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	for _, keyType := range []string{"Secp256k1", "Ed25519"} {
+		var ident Identity
+		var err error
+		if keyType == "Secp256k1" {
+			ident, err = MnemonicIdentityConfig(io.Discard, testMnemonic)
+		} else {
+			ident, err = IdentityConfig(io.Discard, 2048, keyType, "", "")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keyjson, err := EncryptIdentity(&ident, "secret", LightScryptN, LightScryptP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(keyjson), "abandon") {
+			t.Fatal("expected the mnemonic to be encrypted")
+		}
+		got, err := DecryptIdentity(keyjson, "secret")
+		if err != nil {
+			t.Fatalf("%s: %s", keyType, err)
+		}
+		if got.PeerID != ident.PeerID || got.PrivKey != ident.PrivKey || got.BttcAddr != ident.BttcAddr || got.Mnemonic != ident.Mnemonic {
+			t.Fatalf("%s: expected %+v, got %+v", keyType, ident, got)
+		}
+		if _, err := DecryptIdentity(keyjson, "wrong"); !errors.Is(err, ErrKeystorePassword) {
+			t.Fatalf("%s: expected a password error, got %v", keyType, err)
+		}
+
+		other, err := IdentityConfig(io.Discard, 2048, keyType, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tampered := strings.Replace(string(keyjson), ident.PeerID, other.PeerID, 1)
+		if _, err := DecryptIdentity([]byte(tampered), "secret"); err == nil {
+			t.Fatalf("%s: expected an error for a mismatched peer ID", keyType)
+		}
+	}
+}
@@ -3,8 +3,6 @@ package config
 import (
 	"reflect"
 	"strings"
-
-	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 func migrate_1_Services(cfg *Config) bool {
@@ -51,31 +49,18 @@ func migrate_4_SwarmKey(cfg *Config) bool {
 	return false
 }
 
-// checks to see if the current config contains known obsolete ip addresses
-// for bootstrap nodes.
-// Replaces all bootstrap nodes with default values if so.
+// removes retired mainnet bootstrap nodes, see RetiredBootstrapPeers.
 func migrate_5_Bootstrap_node(cfg *Config) bool {
-	// Only migrate on prod settings
-	if cfg.Swarm.SwarmKey != DefaultSwarmKey {
-		return false
-	}
-	obns := []string{
-		"/ip4/34.213.5.20/tcp/4001/p2p/QmQVQBsM7uoJy8hATjTm51uSAkx2y3iGLhSwA6LWLa7iQJ",
-		"/ip4/52.77.240.134/tcp/4001/p2p/QmURPwdLYesWUDB66EGXvDvwcyV44rVRqV2iGNqKN24eVu",
-		"/ip4/3.126.224.22/tcp/4001/p2p/QmWTTmvchTodUaVvuKZMo67xk7ZgkxJf4nBo7SZry3vGU5",
-		"/ip4/18.194.71.27/tcp/4001/p2p/QmYHkY5CrWcvgaDo4PfvzTQgaZtfaqRGDjwW1MrHUj8cLK",
-		"/ip4/18.237.54.123/tcp/4001/p2p/QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g",
-		"/ip4/54.213.128.120/tcp/4001/p2p/QmWm3vBCRuZcJMUT9jDZysoYBb66aokmSReX26UaMk8qq5",
-		"/ip4/18.237.202.91/tcp/4001/p2p/QmbVFdiNkvxtc7Nni7yBWAgtHg8MuyhaZ5mDaYR2ZrhhvN",
-		"/ip4/13.229.45.41/tcp/4001/p2p/QmX7RZXh27AX8iv2BKLGMgPBiuUpEy8p4LFXgtXAfaZDn9",
-		"/ip4/54.254.227.188/tcp/4001/p2p/QmYqCq3PasrzLr3PxtLo5D6spEAJ836W9Re9Eo4zUou45U",
-		"/ip4/54.93.47.134/tcp/4001/p2p/QmeHaHe7WvjeY37z5MYC3qYQcQcuvDwUhwTXtP3KhKLXXK",
-	}
-	peers, _ := DefaultBootstrapPeers()
-	return doMigrateNodes(cfg, obns, peers)
+	return migrateRetiredBootstrap(cfg, NetworkMainnet)
 }
 
 func migrate_6_EnableAutoRelay(cfg *Config) bool {
+	// Swarm.RelayClient.Enabled takes precedence over the deprecated flag,
+	// see migrate_19_RelayFields. The client needs the relay transport.
+	if cfg.Swarm.RelayClient.Enabled != Default || cfg.Swarm.DisableRelay ||
+		!cfg.Swarm.Transports.Network.Relay.WithDefault(DefaultRelayTransportEnabled) {
+		return false
+	}
 	if cfg.Swarm.EnableAutoRelay != DefaultEnableAutoRelay {
 		cfg.Swarm.EnableAutoRelay = DefaultEnableAutoRelay
 		return true
@@ -83,37 +68,9 @@ func migrate_6_EnableAutoRelay(cfg *Config) bool {
 	return false
 }
 
-// checks to see if the current config contains known obsolete ip addresses
-// for testnet bootstrap nodes.
-// Replaces all testnet bootstrap nodes with default values if so.
+// removes retired testnet bootstrap nodes, see RetiredBootstrapPeers.
 func migrate_7_Testnet_Bootstrap_node(cfg *Config) bool {
-	// Only migrate on testnet settings
-	if cfg.Swarm.SwarmKey != DefaultTestnetSwarmKey {
-		return false
-	}
-	obns := []string{
-		"52.57.56.230",
-		"13.59.69.165/tcp/43113",
-		"13.229.73.63/tcp/38869",
-		"3.126.51.74/tcp/38131",
-		"/btfs/", // migrate to ipfs 0.5.0+ protocol
-	}
-	peers, _ := DefaultTestnetBootstrapPeers()
-	return doMigrateNodes(cfg, obns, peers)
-}
-
-func doMigrateNodes(cfg *Config, obsoleteBootstrapNodes []string, defaultPeers []peer.AddrInfo) bool {
-	currentBootstrapNodeList := cfg.Bootstrap
-
-	for _, obsoleteNode := range obsoleteBootstrapNodes {
-		for _, bootstrapNode := range currentBootstrapNodeList {
-			if strings.Contains(bootstrapNode, obsoleteNode) {
-				cfg.SetBootstrapPeers(defaultPeers)
-				return true
-			}
-		}
-	}
-	return false
+	return migrateRetiredBootstrap(cfg, NetworkTestnet)
 }
 
 func migrate_8_AnnounceDefault(cfg *Config, beforeV1B2 bool) bool {
@@ -146,12 +103,13 @@ func migrate_9_WalletDomain(cfg *Config) bool {
 // check if HTTPHeaders, API and Gateway config are fully configured, then clean HTTPHeaders
 func migrate_10_CleanAPIHTTPHeaders(cfg *Config) bool {
 	condCount := 3
-	httpHeaderFullConfig := map[string][]string{
-		"Access-Control-Allow-Origin":      {"*"},
-		"Access-Control-Allow-Methods":     {"PUT", "GET", "POST", "OPTIONS"},
-		"Access-Control-Allow-Credentials": {"true"},
+	// this policy allows credentials from any origin, see CORS.Validate
+	corsFullConfig := &CORS{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"PUT", "GET", "POST", "OPTIONS"},
+		AllowCredentials: true,
 	}
-	if reflect.DeepEqual(cfg.API.HTTPHeaders, httpHeaderFullConfig) {
+	if len(cfg.API.HTTPHeaders) == 3 && CORSFromHTTPHeaders(cfg.API.HTTPHeaders).equal(corsFullConfig) {
 		condCount--
 	}
 	addressesAPIFullConfig := Strings{"/ip4/0.0.0.0/tcp/5001"}
@@ -210,18 +168,12 @@ func migrate_13_HostContractManager(cfg *Config) bool {
 	return false
 }
 
+// migrate_14_TestnetBootstrapNodes is a no-op kept for the migration order.
+// The testnet hosts it retired, on any port, are listed at host level in
+// RetiredBootstrapPeers and removed by migrate_7_Testnet_Bootstrap_node,
+// which runs first.
 func migrate_14_TestnetBootstrapNodes(cfg *Config) bool {
-	// Only migrate on testnet settings
-	if cfg.Swarm.SwarmKey != DefaultTestnetSwarmKey {
-		return false
-	}
-	obns := []string{
-		"13.59.69.165",
-		"13.229.73.63",
-		"3.126.51.74",
-	}
-	peers, _ := DefaultTestnetBootstrapPeers()
-	return doMigrateNodes(cfg, obns, peers)
+	return false
 }
 
 func migrate_15_MissingRemoteAPI(cfg *Config) bool {
@@ -268,6 +220,86 @@ func migrate_18_S3CompatibleAPI(cfg *Config) bool {
 	return false
 }
 
+// moves the deprecated Swarm relay flags into Swarm.Transports.Network.Relay,
+// Swarm.RelayService and Swarm.RelayClient, then clears them.
+// Fields that are already set in the new structure are left untouched.
+func migrate_19_RelayFields(cfg *Config) bool {
+	updated := false
+	if cfg.Swarm.DisableRelay {
+		if cfg.Swarm.Transports.Network.Relay == Default {
+			cfg.Swarm.Transports.Network.Relay = False
+		}
+		cfg.Swarm.DisableRelay = false
+		updated = true
+	}
+	// the relay client and service are only carried over if the relay
+	// transport stays enabled, they require it.
+	transport := cfg.Swarm.Transports.Network.Relay.WithDefault(DefaultRelayTransportEnabled)
+	if cfg.Swarm.EnableRelayHop {
+		if transport && cfg.Swarm.RelayService.Enabled == Default {
+			cfg.Swarm.RelayService.Enabled = True
+		}
+		cfg.Swarm.EnableRelayHop = false
+		updated = true
+	}
+	if cfg.Swarm.EnableAutoRelay {
+		if transport && cfg.Swarm.RelayClient.Enabled == Default {
+			cfg.Swarm.RelayClient.Enabled = True
+		}
+		cfg.Swarm.EnableAutoRelay = false
+		updated = true
+	}
+	return updated
+}
+
+// fills the region and the object size limits of the S3-compatible API that
+// were added after migrate_18_S3CompatibleAPI.
+// Access keys are never generated here, an enabled API without keys fails
+// validation instead.
+func migrate_20_S3CompatibleAPILimits(cfg *Config) bool {
+	updated := false
+	ds := DefaultS3CompatibleAPIConfig()
+	if cfg.S3CompatibleAPI.Region == "" {
+		cfg.S3CompatibleAPI.Region = ds.Region
+		updated = true
+	}
+	if cfg.S3CompatibleAPI.MaxObjectSize == 0 {
+		cfg.S3CompatibleAPI.MaxObjectSize = ds.MaxObjectSize
+		updated = true
+	}
+	if cfg.S3CompatibleAPI.MultipartMaxParts == 0 {
+		cfg.S3CompatibleAPI.MultipartMaxParts = ds.MultipartMaxParts
+		updated = true
+	}
+	if cfg.S3CompatibleAPI.MultipartMinPartSize == 0 {
+		cfg.S3CompatibleAPI.MultipartMinPartSize = ds.MultipartMinPartSize
+		updated = true
+	}
+	return updated
+}
+
+// resets Datastore.GCPeriod, Ipns.RepublishPeriod and Ipns.RecordLifetime to
+// their defaults, each only when it is out of its own range. The republish
+// period is reset first, so a record lifetime is only dropped if it does not
+// outlive the resulting republish period. Other fields are left alone, even
+// if they are invalid.
+func migrate_21_Periods(cfg *Config) bool {
+	updated := false
+	if !cfg.Datastore.GCPeriod.IsDefault() && cfg.Datastore.validateGCPeriod() != nil {
+		cfg.Datastore.GCPeriod = nil
+		updated = true
+	}
+	if !cfg.Ipns.RepublishPeriod.IsDefault() && cfg.Ipns.validateRepublishPeriod() != nil {
+		cfg.Ipns.RepublishPeriod = nil
+		updated = true
+	}
+	if !cfg.Ipns.RecordLifetime.IsDefault() && cfg.Ipns.validateRecordLifetime() != nil {
+		cfg.Ipns.RecordLifetime = nil
+		updated = true
+	}
+	return updated
+}
+
 // MigrateConfig migrates config options to the latest known version
 // It may correct incompatible configs as well
 // inited = just initialized in the same call
@@ -294,5 +326,8 @@ func MigrateConfig(cfg *Config, inited, hasHval bool) bool {
 	updated = migrate_16_TrongridDomain(cfg) || updated
 	updated = migrate_17_Sync_Hosts(cfg) || updated
 	updated = migrate_18_S3CompatibleAPI(cfg) || updated
+	updated = migrate_19_RelayFields(cfg) || updated
+	updated = migrate_20_S3CompatibleAPILimits(cfg) || updated
+	updated = migrate_21_Periods(cfg) || updated
 	return updated
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultMnemonicEntropyBits is the entropy of generated mnemonics, which
+// gives 12 words.
+const DefaultMnemonicEntropyBits = 128
+
+// DefaultMnemonicDerivationPath is the BIP-44 path of the node key derived
+// from a mnemonic. It is the first account of the Ethereum coin type, so the
+// node key doubles as the BTTC wallet key in standard wallets.
+const DefaultMnemonicDerivationPath = "m/44'/60'/0'/0/0"
+
+// ErrInvalidMnemonic is returned for mnemonics with unknown words or a bad
+// checksum.
+var ErrInvalidMnemonic = errors.New("invalid BIP-39 mnemonic")
+
+const bip32HardenedOffset = 0x80000000
+
+// GenerateMnemonic returns a new English BIP-39 mnemonic with the given
+// entropy, a multiple of 32 between 128 and 256 bits.
+func GenerateMnemonic(entropyBits int) (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic checks the words of a mnemonic against the English BIP-39
+// wordlist and verifies its checksum.
+func ValidateMnemonic(mnemonic string) error {
+	if _, err := bip39.MnemonicToByteArray(normalizeMnemonic(mnemonic)); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMnemonic, err)
+	}
+	return nil
+}
+
+// PrivKeyFromMnemonic derives a secp256k1 key from a BIP-39 mnemonic and an
+// optional password, following the BIP-32 derivation path.
+func PrivKeyFromMnemonic(mnemonic, password, path string) (ic.PrivKey, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed := bip39.NewSeed(normalizeMnemonic(mnemonic), password)
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range indexes {
+		key, chainCode, err = deriveBip32Child(key, chainCode, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ic.UnmarshalSecp256k1PrivateKey(key)
+}
+
+// deriveBip32Child derives the private child key at index.
+func deriveBip32Child(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= bip32HardenedOffset {
+		data = append([]byte{0}, key...)
+	} else {
+		data = secp256k1.PrivKeyFromBytes(key).PubKey().SerializeCompressed()
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	var il, k secp256k1.ModNScalar
+	if overflow := il.SetByteSlice(sum[:32]); overflow {
+		return nil, nil, fmt.Errorf("invalid BIP-32 child key at index %d", index)
+	}
+	k.SetByteSlice(key)
+	k.Add(&il)
+	if k.IsZero() {
+		return nil, nil, fmt.Errorf("invalid BIP-32 child key at index %d", index)
+	}
+	child := k.Bytes()
+	return child[:], sum[32:], nil
+}
+
+// parseDerivationPath parses a BIP-32 path such as m/44'/60'/0'/0/0.
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		hardened := strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h")
+		if hardened {
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: %s", path, err)
+		}
+		index := uint32(i)
+		if hardened {
+			index += bip32HardenedOffset
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
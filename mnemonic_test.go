@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestPrivKeyFromMnemonic(t *testing.T) {
+	sk, err := PrivKeyFromMnemonic(testMnemonic, "", DefaultMnemonicDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sk.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(raw); got != "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727" {
+		t.Fatalf("unexpected key %s", got)
+	}
+	addr, err := BttcAddrFromPubKey(sk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("unexpected address %s", addr)
+	}
+
+	other, err := PrivKeyFromMnemonic(testMnemonic, "password", DefaultMnemonicDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Equals(sk) {
+		t.Fatal("expected the password to change the derived key")
+	}
+}
+
+func TestMnemonicValidation(t *testing.T) {
+	if err := ValidateMnemonic(testMnemonic); err != nil {
+		t.Fatal(err)
+	}
+	bad := strings.Replace(testMnemonic, "about", "abandon", 1)
+	if err := ValidateMnemonic(bad); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if err := ValidateMnemonic("not a real mnemonic"); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected a wordlist error, got %v", err)
+	}
+	for _, path := range []string{"", "44'/60'", "m/x", "m/44'/-1"} {
+		if _, err := PrivKeyFromMnemonic(testMnemonic, "", path); err == nil {
+			t.Fatalf("expected an error for path %q", path)
+		}
+	}
+
+	m, err := GenerateMnemonic(DefaultMnemonicEntropyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.Fields(m)) != 12 {
+		t.Fatalf("expected 12 words, got %q", m)
+	}
+	if err := ValidateMnemonic(m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMnemonicIdentityConfig(t *testing.T) {
+	a, err := MnemonicIdentityConfig(io.Discard, testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MnemonicIdentityConfig(io.Discard, testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.PeerID != b.PeerID || a.BttcAddr != b.BttcAddr || a.Mnemonic != testMnemonic {
+		t.Fatalf("expected the same identity from the same mnemonic, got %+v and %+v", a, b)
+	}
+	if a.BttcAddr != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("unexpected address %s", a.BttcAddr)
+	}
+
+	rsa, err := IdentityConfig(io.Discard, 2048, "RSA", "", testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsa.PeerID == a.PeerID || rsa.Mnemonic != testMnemonic {
+		t.Fatal("expected IdentityConfig to generate a key and only record the mnemonic")
+	}
+	if _, err := MnemonicIdentityConfig(io.Discard, "abandon abandon"); err == nil {
+		t.Fatal("expected an error for an invalid mnemonic")
+	}
+
+	c, err := MnemonicIdentityConfig(io.Discard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := PrivKeyFromMnemonic(c.Mnemonic, "", DefaultMnemonicDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := c.DecodePrivateKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equals(sk) || decoded.Type() != ic.Secp256k1 {
+		t.Fatal("expected the generated identity to be recoverable from its mnemonic")
+	}
+}
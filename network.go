@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Network is the BTFS network a setting belongs to.
+type Network string
+
+const (
+	// NetworkUnknown is used for settings that do not belong to a known
+	// network, e.g. custom service domains or a private swarm key.
+	NetworkUnknown Network = ""
+	NetworkMainnet Network = "mainnet"
+	NetworkTestnet Network = "testnet"
+)
+
+func (n Network) String() string {
+	if n == NetworkUnknown {
+		return "unknown"
+	}
+	return string(n)
+}
+
+// ChainNetwork classifies a chain ID using the chain registry.
+func ChainNetwork(chainID int64) Network {
+	cs, ok := LookupChain(chainID)
+	switch {
+	case !ok:
+		return NetworkUnknown
+	case cs.Testnet:
+		return NetworkTestnet
+	default:
+		return NetworkMainnet
+	}
+}
+
+// SwarmKeyNetwork classifies a swarm key.
+func SwarmKeyNetwork(swarmKey string) Network {
+	switch strings.TrimSpace(swarmKey) {
+	case DefaultSwarmKey:
+		return NetworkMainnet
+	case DefaultTestnetSwarmKey:
+		return NetworkTestnet
+	default:
+		return NetworkUnknown
+	}
+}
+
+// BootstrapNetwork classifies a bootstrap list by comparing its peer IDs with
+// the default bootstrap peers of each network. Entries that are not default
+// peers are ignored. It fails if the list mixes peers of several networks.
+func BootstrapNetwork(bootstrap []string) (Network, error) {
+	votes := newNetworkVotes()
+	mainnet := bootstrapPeerIDs(DefaultBootstrapAddresses)
+	testnet := bootstrapPeerIDs(DefaultTestnetBootstrapAddresses)
+	for id := range bootstrapPeerIDs(bootstrap) {
+		if mainnet[id] {
+			votes.add(NetworkMainnet, id.String())
+		}
+		if testnet[id] {
+			votes.add(NetworkTestnet, id.String())
+		}
+	}
+	return votes.result("Bootstrap")
+}
+
+// ServicesNetwork classifies the service endpoints and keys by comparing
+// them with the default services of each network. Values shared by several
+// networks or not found in any default are ignored. It fails if the services
+// point at several networks.
+func ServicesNetwork(s Services) (Network, error) {
+	known := map[Network][]Services{
+		NetworkMainnet: {DefaultServicesConfig()},
+		NetworkTestnet: {DefaultServicesConfigDev(), DefaultServicesConfigTestnet()},
+	}
+	owners := make(map[string]map[Network]bool)
+	for n, defaults := range known {
+		for _, ds := range defaults {
+			for _, v := range servicesSignals(ds) {
+				if owners[v] == nil {
+					owners[v] = make(map[Network]bool)
+				}
+				owners[v][n] = true
+			}
+		}
+	}
+
+	votes := newNetworkVotes()
+	for _, v := range servicesSignals(s) {
+		if len(owners[v]) != 1 {
+			continue
+		}
+		for n := range owners[v] {
+			votes.add(n, v)
+		}
+	}
+	return votes.result("Services")
+}
+
+// servicesSignals returns the hosts of the service endpoints and the
+// escrow/guard keys of s.
+func servicesSignals(s Services) []string {
+	var out []string
+	for _, ss := range ServiceRegistry {
+		ep := *ss.domain(&s)
+		if ss.Kind == EndpointURL {
+			if u, err := url.Parse(ep); err == nil {
+				ep = u.Host
+			}
+		}
+		if ep != "" {
+			out = append(out, ep)
+		}
+	}
+	out = append(out, s.EscrowPubKeys...)
+	out = append(out, s.GuardPubKeys...)
+	return out
+}
+
+// CheckNetworkConsistency classifies ChainInfo.ChainId, Swarm.SwarmKey,
+// Bootstrap and Services into a network and returns an error describing any
+// mismatch. Settings that do not belong to a known network are not checked.
+func (c *Config) CheckNetworkConsistency() (Network, error) {
+	type setting struct {
+		name    string
+		network Network
+	}
+	settings := []setting{
+		{"ChainInfo.ChainId", ChainNetwork(c.ChainInfo.ChainId)},
+		{"Swarm.SwarmKey", SwarmKeyNetwork(c.Swarm.SwarmKey)},
+	}
+	bn, err := BootstrapNetwork(c.Bootstrap)
+	if err != nil {
+		return NetworkUnknown, err
+	}
+	settings = append(settings, setting{"Bootstrap", bn})
+	sn, err := ServicesNetwork(c.Services)
+	if err != nil {
+		return NetworkUnknown, err
+	}
+	settings = append(settings, setting{"Services", sn})
+
+	network := NetworkUnknown
+	var found []string
+	mismatch := false
+	for _, s := range settings {
+		if s.network == NetworkUnknown {
+			continue
+		}
+		found = append(found, fmt.Sprintf("%s is %s", s.name, s.network))
+		if network == NetworkUnknown {
+			network = s.network
+		} else if network != s.network {
+			mismatch = true
+		}
+	}
+	if mismatch {
+		return NetworkUnknown, fmt.Errorf("network mismatch: %s", strings.Join(found, ", "))
+	}
+	return network, nil
+}
+
+func bootstrapPeerIDs(addrs []string) map[peer.ID]bool {
+	ids := make(map[peer.ID]bool)
+	for _, a := range addrs {
+		pis, err := ParseBootstrapPeers([]string{a})
+		if err != nil {
+			continue
+		}
+		for _, pi := range pis {
+			ids[pi.ID] = true
+		}
+	}
+	return ids
+}
+
+// networkVotes collects the networks a setting's values belong to.
+type networkVotes map[Network][]string
+
+func newNetworkVotes() networkVotes {
+	return make(networkVotes)
+}
+
+func (v networkVotes) add(n Network, value string) {
+	v[n] = append(v[n], value)
+}
+
+func (v networkVotes) result(name string) (Network, error) {
+	switch len(v) {
+	case 0:
+		return NetworkUnknown, nil
+	case 1:
+		for n := range v {
+			return n, nil
+		}
+	}
+	return NetworkUnknown, fmt.Errorf("%s mixes mainnet (%s) and testnet (%s) values", name,
+		strings.Join(v[NetworkMainnet], ", "), strings.Join(v[NetworkTestnet], ", "))
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestCheckNetworkConsistency(t *testing.T) {
+	for profile, expected := range map[string]Network{
+		"storage-host":           NetworkMainnet,
+		"storage-host-dev":       NetworkTestnet,
+		"storage-host-testnet":   NetworkTestnet,
+		"storage-client-testnet": NetworkTestnet,
+	} {
+		cfg := &Config{}
+		if err := Profiles[profile].Transform(cfg); err != nil {
+			t.Fatal(err)
+		}
+		n, err := cfg.CheckNetworkConsistency()
+		if err != nil {
+			t.Fatalf("%s: %s", profile, err)
+		}
+		if n != expected {
+			t.Fatalf("%s: expected %s, got %s", profile, expected, n)
+		}
+	}
+
+	cfg := &Config{}
+	if n, err := cfg.CheckNetworkConsistency(); err != nil || n != NetworkUnknown {
+		t.Fatalf("expected an empty config to be unknown, got %s, %v", n, err)
+	}
+
+	// mainnet chain with testnet swarm key and staging services
+	cfg = &Config{}
+	if err := Profiles["storage-host-testnet"].Transform(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ChainInfo.ChainId = BttcChainID
+	if _, err := cfg.CheckNetworkConsistency(); err == nil {
+		t.Fatal("expected a network mismatch")
+	}
+
+	cfg = &Config{}
+	if err := Profiles["storage-host"].Transform(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Services.HubDomain = DefaultServicesConfigTestnet().HubDomain
+	if _, err := cfg.CheckNetworkConsistency(); err == nil {
+		t.Fatal("expected mixed services to be rejected")
+	}
+
+	cfg = &Config{}
+	if err := Profiles["storage-host"].Transform(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Bootstrap = append(cfg.Bootstrap, DefaultTestnetBootstrapAddresses[0])
+	if _, err := cfg.CheckNetworkConsistency(); err == nil {
+		t.Fatal("expected a mixed bootstrap list to be rejected")
+	}
+
+	// custom services and a private swarm key are not classified
+	cfg = &Config{}
+	if err := Profiles["storage-host"].Transform(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Services.HubDomain = "https://hub.example.com"
+	cfg.Swarm.SwarmKey = "/key/swarm/psk/1.0.0/\n/base16/\n00"
+	if n, err := cfg.CheckNetworkConsistency(); err != nil || n != NetworkMainnet {
+		t.Fatalf("expected mainnet, got %s, %v", n, err)
+	}
+}
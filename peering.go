@@ -1,9 +1,186 @@
 package config
 
-import "github.com/libp2p/go-libp2p/core/peer"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
 
 // Peering configures the peering service.
 type Peering struct {
 	// Peers lists the nodes to attempt to stay connected with.
 	Peers []peer.AddrInfo
 }
+
+// AddPeers adds peers from multiaddrs ending in a /p2p/ component, merging
+// the addresses of peers that are already listed. Nothing is added if any
+// address is invalid or is a bare /p2p/<peer ID> without an address.
+func (p *Peering) AddPeers(addrs ...string) error {
+	maddrs := make([]ma.Multiaddr, len(addrs))
+	for i, addr := range addrs {
+		var err error
+		if maddrs[i], err = parsePeeringAddr(addr); err != nil {
+			return err
+		}
+	}
+	infos, err := groupAddrInfos(maddrs)
+	if err != nil {
+		return err
+	}
+	return p.Merge(infos...)
+}
+
+// Merge adds peers, merging the addresses of peers that are already listed.
+// Nothing is added if any peer has no addresses.
+func (p *Peering) Merge(infos ...peer.AddrInfo) error {
+	for _, info := range infos {
+		if err := validatePeerAddrs(info); err != nil {
+			return err
+		}
+	}
+	for _, info := range infos {
+		i := p.index(info.ID)
+		if i < 0 {
+			p.Peers = append(p.Peers, peer.AddrInfo{ID: info.ID, Addrs: append([]ma.Multiaddr{}, info.Addrs...)})
+			continue
+		}
+		for _, a := range info.Addrs {
+			if !containsMultiaddr(p.Peers[i].Addrs, a) {
+				p.Peers[i].Addrs = append(p.Peers[i].Addrs, a)
+			}
+		}
+	}
+	return nil
+}
+
+// RemovePeer removes a peer and reports whether it was listed.
+func (p *Peering) RemovePeer(id peer.ID) bool {
+	i := p.index(id)
+	if i < 0 {
+		return false
+	}
+	p.Peers = append(p.Peers[:i], p.Peers[i+1:]...)
+	return true
+}
+
+// Validate checks that every peer is listed once, has at least one address
+// and is not the local node, whose peer ID is self.
+func (p *Peering) Validate(self string) error {
+	seen := map[peer.ID]bool{}
+	for _, info := range p.Peers {
+		if err := info.ID.Validate(); err != nil {
+			return fmt.Errorf("Peering.Peers: invalid peer ID %q: %s", info.ID, err)
+		}
+		if info.ID.String() == self {
+			return fmt.Errorf("Peering.Peers: %s is the local node", info.ID)
+		}
+		if seen[info.ID] {
+			return fmt.Errorf("Peering.Peers: %s is listed more than once", info.ID)
+		}
+		seen[info.ID] = true
+		if err := validatePeerAddrs(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportFile merges the peers of a peering list file, see ReadPeeringList.
+func (p *Peering) ImportFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	infos, err := ReadPeeringList(f)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return p.Merge(infos...)
+}
+
+// ExportFile writes the peers to a peering list file, see WritePeeringList.
+func (p *Peering) ExportFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WritePeeringList(f, p.Peers); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadPeeringList parses a peering list: one multiaddr ending in a /p2p/
+// component per line; a bare /p2p/<peer ID> is rejected. Blank lines and
+// lines starting with # are ignored. Addresses of the same peer are merged.
+func ReadPeeringList(r io.Reader) ([]peer.AddrInfo, error) {
+	var maddrs []ma.Multiaddr
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		maddr, err := parsePeeringAddr(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		maddrs = append(maddrs, maddr)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return groupAddrInfos(maddrs)
+}
+
+// WritePeeringList writes peers in the format read by ReadPeeringList.
+func WritePeeringList(w io.Writer, peers []peer.AddrInfo) error {
+	for _, info := range peers {
+		maddrs, err := peer.AddrInfoToP2pAddrs(&info)
+		if err != nil {
+			return err
+		}
+		for _, maddr := range maddrs {
+			if _, err := fmt.Fprintln(w, maddr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parsePeeringAddr parses a peering entry: a multiaddr with an address
+// followed by a /p2p/ component.
+func parsePeeringAddr(addr string) (ma.Multiaddr, error) {
+	maddr, err := parseP2pAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if transport, _ := peer.SplitAddr(maddr); transport == nil {
+		return nil, fmt.Errorf("%w %q: has no address before /p2p/", ErrInvalidPeerAddr, addr)
+	}
+	return maddr, nil
+}
+
+func validatePeerAddrs(info peer.AddrInfo) error {
+	if len(info.Addrs) == 0 {
+		return fmt.Errorf("Peering.Peers: %s has no addresses", info.ID)
+	}
+	return nil
+}
+
+func (p *Peering) index(id peer.ID) int {
+	for i := range p.Peers {
+		if p.Peers[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPeering(t *testing.T) {
+	var p Peering
+	err := p.AddPeers(
+		"/ip4/1.2.3.4/tcp/4001/p2p/"+testPeerA,
+		"/ip4/5.6.7.8/tcp/4001/p2p/"+testPeerB,
+		"/ip4/1.2.3.4/udp/4001/quic-v1/p2p/"+testPeerA,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Peers) != 2 || len(p.Peers[0].Addrs) != 2 || p.Peers[0].ID.String() != testPeerA {
+		t.Fatalf("expected two peers with peer A first, got %v", p.Peers)
+	}
+	if err := p.AddPeers("/ip4/1.2.3.4/tcp/4001/p2p/"+testPeerA, "/ip4/9.9.9.9/tcp/4001"); err == nil {
+		t.Fatal("expected an error for an address without /p2p/")
+	}
+	if err := p.AddPeers("/ip4/1.2.3.4/tcp/4001/p2p/"+testPeerA, "/p2p/"+testPeerB); err == nil || len(p.Peers[1].Addrs) != 1 {
+		t.Fatalf("expected an error for an address-less entry, got %v", err)
+	}
+	if err := p.Merge(peer.AddrInfo{ID: p.Peers[1].ID}); err == nil {
+		t.Fatal("expected an error for merging a peer without addresses")
+	}
+	if err := p.AddPeers("/ip4/1.2.3.4/tcp/4001/p2p/" + testPeerA); err != nil || len(p.Peers[0].Addrs) != 2 {
+		t.Fatalf("expected a known address to be merged, got %v, %v", p.Peers[0], err)
+	}
+
+	if err := p.Validate(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(testPeerB); err == nil {
+		t.Fatal("expected an error for the local peer")
+	}
+	idA, _ := peer.Decode(testPeerA)
+	p.Peers = append(p.Peers, peer.AddrInfo{ID: idA})
+	if err := p.Validate(""); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatalf("expected a duplicate peer error, got %v", err)
+	}
+	p.Peers = p.Peers[:2]
+
+	if !p.RemovePeer(idA) || p.RemovePeer(idA) || len(p.Peers) != 1 {
+		t.Fatalf("expected peer A to be removed once, got %v", p.Peers)
+	}
+	p.Peers[0].Addrs = nil
+	if err := p.Validate(""); err == nil {
+		t.Fatal("expected an error for a peer without addresses")
+	}
+}
+
+func TestPeeringFile(t *testing.T) {
+	var p Peering
+	if err := p.AddPeers(
+		"/ip4/1.2.3.4/tcp/4001/p2p/"+testPeerA,
+		"/ip4/1.2.3.4/udp/4001/quic-v1/p2p/"+testPeerA,
+		"/dns4/renter.example.com/tcp/4001/p2p/"+testPeerB,
+	); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "peering.txt")
+	if err := p.ExportFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	var imported Peering
+	if err := imported.ImportFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(imported.Peers) != 2 || len(imported.Peers[0].Addrs) != 2 || imported.Peers[1].ID != p.Peers[1].ID {
+		t.Fatalf("unexpected peers %v", imported.Peers)
+	}
+
+	list := "# renters\n\n/ip4/5.6.7.8/tcp/4001/p2p/" + testPeerB + "\n  /ip4/5.6.7.9/tcp/4001/p2p/" + testPeerB + "  \n"
+	infos, err := ReadPeeringList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || len(infos[0].Addrs) != 2 {
+		t.Fatalf("unexpected peers %v", infos)
+	}
+	if _, err := ReadPeeringList(strings.NewReader("# ok\nnot an address\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error on line 2, got %v", err)
+	}
+	if _, err := ReadPeeringList(strings.NewReader("/p2p/" + testPeerA + "\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected an error for an address-less entry, got %v", err)
+	}
+}
@@ -20,10 +20,22 @@ type Profile struct {
 	// Transform takes ipfs configuration and applies the profile to it.
 	Transform Transformer
 
+	// RepoTransform, if set, applies the profile to the configuration of the
+	// repo at configroot. Transform applies it to the default repo.
+	RepoTransform func(c *Config, configroot string) error
+
 	// InitOnly specifies that this profile can only be applied on init.
 	InitOnly bool
 }
 
+// Apply applies the profile to the configuration of the repo at configroot.
+func (p Profile) Apply(c *Config, configroot string) error {
+	if p.RepoTransform != nil {
+		return p.RepoTransform(c, configroot)
+	}
+	return p.Transform(c)
+}
+
 // defaultServerFilters has is a list of IPv4 and IPv6 prefixes that are private, local only, or unrouteable.
 // according to https://www.iana.org/assignments/iana-ipv4-special-registry/iana-ipv4-special-registry.xhtml
 // and https://www.iana.org/assignments/iana-ipv6-special-registry/iana-ipv6-special-registry.xhtml
@@ -275,17 +287,27 @@ fetching may be degraded.
 			if len(c.Addresses.RemoteAPI) == 0 {
 				c.Addresses.RemoteAPI = Strings{"/ip4/0.0.0.0/tcp/5101"}
 			}
-			if c.Datastore.StorageMax == "10GB" {
-				c.Datastore.StorageMax = "1TB"
+			if c.Datastore.StorageMax == DefaultStorageMax && !c.Datastore.AutoStorageMax {
+				c.Datastore.StorageMax = DefaultHostStorageMax
 			}
 			c.Services = DefaultServicesConfig()
 			c.Swarm.SwarmKey = DefaultSwarmKey
 			c.ChainInfo = ChainInfo{
-				ChainId: bttcChainID,
+				ChainId: BttcChainID,
 			}
 			return nil
 		},
 	},
+	"storage-autosize": {
+		Description: `Sets Datastore.StorageMax to fit the free space of the disk
+the datastore is created on. Apply it after storage-host, which sets 1TB.`,
+
+		Transform: func(c *Config) error {
+			return autosizeStorage(c, "")
+		},
+		RepoTransform: autosizeStorage,
+		InitOnly:      true,
+	},
 	"storage-host-dev": {
 		Description: `[dev] Configures necessary flags and options for node to become a storage host.`,
 
@@ -411,13 +433,13 @@ func transformDevStorageHost(c *Config) error {
 	if len(c.Addresses.RemoteAPI) == 0 {
 		c.Addresses.RemoteAPI = Strings{"/ip4/0.0.0.0/tcp/5101"}
 	}
-	if c.Datastore.StorageMax == "10GB" {
-		c.Datastore.StorageMax = "1TB"
+	if c.Datastore.StorageMax == DefaultStorageMax && !c.Datastore.AutoStorageMax {
+		c.Datastore.StorageMax = DefaultHostStorageMax
 	}
 	c.Services = DefaultServicesConfigDev()
 	c.Swarm.SwarmKey = DefaultTestnetSwarmKey
 	c.ChainInfo = ChainInfo{
-		ChainId: bttcTestChainID,
+		ChainId: BttcTestChainID,
 	}
 	return nil
 }
@@ -455,7 +477,7 @@ func transformDevStorageClient(c *Config) error {
 		c.Addresses.RemoteAPI = Strings{"/ip4/0.0.0.0/tcp/5101"}
 	}
 	c.ChainInfo = ChainInfo{
-		ChainId: bttcTestChainID,
+		ChainId: BttcTestChainID,
 	}
 	c.Swarm.SwarmKey = DefaultTestnetSwarmKey
 	return nil
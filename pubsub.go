@@ -1,11 +1,211 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
+// PubsubRouter is the pubsub routing protocol.
+type PubsubRouter string
+
+const (
+	// FloodsubRouter is the legacy floodsub router.
+	FloodsubRouter PubsubRouter = "floodsub"
+	// GossipsubRouter is the gossipsub router, backwards compatible with
+	// floodsub peers.
+	GossipsubRouter PubsubRouter = "gossipsub"
+
+	DefaultPubsubRouter = GossipsubRouter
+)
+
+// Seen messages cache strategies.
+const (
+	// LastSeenMessagesStrategy expires a message ID SeenMessagesTTL after it
+	// was last seen.
+	LastSeenMessagesStrategy = "last-seen"
+	// FirstSeenMessagesStrategy expires a message ID SeenMessagesTTL after it
+	// was first seen.
+	FirstSeenMessagesStrategy = "first-seen"
+
+	DefaultSeenMessagesStrategy = LastSeenMessagesStrategy
+)
+
+// DefaultSeenMessagesTTL is the default value for Pubsub.SeenMessagesTTL.
+const DefaultSeenMessagesTTL = 120 * time.Second
+
+// Gossipsub defaults. They match the libp2p gossipsub defaults.
+const (
+	DefaultGossipsubD                 = 6
+	DefaultGossipsubDlo               = 5
+	DefaultGossipsubDhi               = 12
+	DefaultGossipsubHeartbeatInterval = time.Second
+	DefaultGossipsubHistoryLength     = 5
+	DefaultGossipsubHistoryGossip     = 3
+	DefaultGossipsubFloodPublish      = true
+)
+
 type PubsubConfig struct {
 	// Router can be either floodsub (legacy) or gossipsub (new and
-	// backwards compatible).
-	Router string
+	// backwards compatible). Defaults to gossipsub.
+	Router PubsubRouter
 
 	// DisableSigning disables message signing. Message signing is *enabled*
 	// by default.
 	DisableSigning bool
+
+	// SeenMessagesTTL is how long a message ID is remembered to drop
+	// duplicates.
+	SeenMessagesTTL *OptionalDuration `json:",omitempty"`
+
+	// SeenMessagesStrategy is either last-seen or first-seen, see
+	// LastSeenMessagesStrategy and FirstSeenMessagesStrategy.
+	SeenMessagesStrategy *OptionalString `json:",omitempty"`
+
+	// Gossipsub tunes the gossipsub router.
+	Gossipsub *GossipsubConfig `json:",omitempty"`
+}
+
+// GossipsubConfig tunes the gossipsub router. Unset fields use the
+// DefaultGossipsub* values.
+type GossipsubConfig struct {
+	// D is the desired number of peers in the mesh of a topic, kept between
+	// Dlo and Dhi.
+	D   *OptionalInteger `json:",omitempty"`
+	Dlo *OptionalInteger `json:",omitempty"`
+	Dhi *OptionalInteger `json:",omitempty"`
+
+	// HeartbeatInterval is the interval of mesh maintenance.
+	HeartbeatInterval *OptionalDuration `json:",omitempty"`
+
+	// HistoryLength is the number of heartbeats messages are cached for,
+	// HistoryGossip the number of those heartbeats gossiped about.
+	HistoryLength *OptionalInteger `json:",omitempty"`
+	HistoryGossip *OptionalInteger `json:",omitempty"`
+
+	// FloodPublish publishes own messages to all peers with a score above
+	// the publish threshold, not just the mesh.
+	FloodPublish Flag `json:",omitempty"`
+
+	// PeerScoreThresholds enables peer scoring with these thresholds.
+	// Scoring is disabled if nil.
+	PeerScoreThresholds *GossipsubScoreThresholds `json:",omitempty"`
+}
+
+// GossipsubScoreThresholds are the peer score thresholds of gossipsub.
+type GossipsubScoreThresholds struct {
+	// GossipThreshold is the score below which gossip is not exchanged
+	// with a peer. It must not be positive.
+	GossipThreshold float64
+	// PublishThreshold is the score below which own messages are not
+	// flood-published to a peer. It must not be above GossipThreshold.
+	PublishThreshold float64
+	// GraylistThreshold is the score below which all messages of a peer are
+	// ignored. It must not be above PublishThreshold.
+	GraylistThreshold float64
+	// AcceptPXThreshold is the score a peer needs for its peer exchange to
+	// be accepted. It must not be negative.
+	AcceptPXThreshold float64
+	// OpportunisticGraftThreshold is the median mesh score below which
+	// better peers are grafted. It must not be negative.
+	OpportunisticGraftThreshold float64
+}
+
+// DefaultGossipsubScoreThresholds returns the thresholds used when peer
+// scoring is enabled without tuning.
+func DefaultGossipsubScoreThresholds() *GossipsubScoreThresholds {
+	return &GossipsubScoreThresholds{
+		GossipThreshold:             -500,
+		PublishThreshold:            -1000,
+		GraylistThreshold:           -2500,
+		AcceptPXThreshold:           1000,
+		OpportunisticGraftThreshold: 3.5,
+	}
+}
+
+// GossipsubParams is GossipsubConfig with every unset field resolved to its
+// default value.
+type GossipsubParams struct {
+	D                   int
+	Dlo                 int
+	Dhi                 int
+	HeartbeatInterval   time.Duration
+	HistoryLength       int
+	HistoryGossip       int
+	FloodPublish        bool
+	PeerScoreThresholds *GossipsubScoreThresholds
+}
+
+// Params returns the gossipsub parameters with defaults filled in, and
+// checks that Dlo <= D <= Dhi, that HistoryGossip <= HistoryLength and that
+// the score thresholds are ordered.
+func (g *GossipsubConfig) Params() (GossipsubParams, error) {
+	if g == nil {
+		g = &GossipsubConfig{}
+	}
+	p := GossipsubParams{
+		D:                   int(g.D.WithDefault(DefaultGossipsubD)),
+		Dlo:                 int(g.Dlo.WithDefault(DefaultGossipsubDlo)),
+		Dhi:                 int(g.Dhi.WithDefault(DefaultGossipsubDhi)),
+		HeartbeatInterval:   g.HeartbeatInterval.WithDefault(DefaultGossipsubHeartbeatInterval),
+		HistoryLength:       int(g.HistoryLength.WithDefault(DefaultGossipsubHistoryLength)),
+		HistoryGossip:       int(g.HistoryGossip.WithDefault(DefaultGossipsubHistoryGossip)),
+		FloodPublish:        g.FloodPublish.WithDefault(DefaultGossipsubFloodPublish),
+		PeerScoreThresholds: g.PeerScoreThresholds,
+	}
+
+	if p.Dlo <= 0 || p.Dlo > p.D || p.D > p.Dhi {
+		return p, fmt.Errorf("Pubsub.Gossipsub: Dlo (%d), D (%d) and Dhi (%d) must satisfy 0 < Dlo <= D <= Dhi", p.Dlo, p.D, p.Dhi)
+	}
+	if p.HeartbeatInterval <= 0 {
+		return p, fmt.Errorf("Pubsub.Gossipsub.HeartbeatInterval must be positive, got %s", p.HeartbeatInterval)
+	}
+	if p.HistoryGossip <= 0 || p.HistoryGossip > p.HistoryLength {
+		return p, fmt.Errorf("Pubsub.Gossipsub: HistoryGossip (%d) must be positive and at most HistoryLength (%d)", p.HistoryGossip, p.HistoryLength)
+	}
+	if t := p.PeerScoreThresholds; t != nil {
+		switch {
+		case t.GossipThreshold > 0:
+			return p, fmt.Errorf("Pubsub.Gossipsub.PeerScoreThresholds.GossipThreshold must not be positive")
+		case t.PublishThreshold > t.GossipThreshold:
+			return p, fmt.Errorf("Pubsub.Gossipsub.PeerScoreThresholds.PublishThreshold must not be above GossipThreshold")
+		case t.GraylistThreshold > t.PublishThreshold:
+			return p, fmt.Errorf("Pubsub.Gossipsub.PeerScoreThresholds.GraylistThreshold must not be above PublishThreshold")
+		case t.AcceptPXThreshold < 0:
+			return p, fmt.Errorf("Pubsub.Gossipsub.PeerScoreThresholds.AcceptPXThreshold must not be negative")
+		case t.OpportunisticGraftThreshold < 0:
+			return p, fmt.Errorf("Pubsub.Gossipsub.PeerScoreThresholds.OpportunisticGraftThreshold must not be negative")
+		}
+	}
+	return p, nil
+}
+
+// Validate checks the router, the seen messages cache and, for gossipsub,
+// the router parameters.
+func (c *PubsubConfig) Validate() error {
+	router := c.Router
+	if router == "" {
+		router = DefaultPubsubRouter
+	}
+	switch router {
+	case FloodsubRouter:
+		if c.Gossipsub != nil {
+			return fmt.Errorf("Pubsub.Gossipsub is set but Pubsub.Router is %s", router)
+		}
+	case GossipsubRouter:
+		if _, err := c.Gossipsub.Params(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown Pubsub.Router %q, expected %s or %s", c.Router, FloodsubRouter, GossipsubRouter)
+	}
+
+	if ttl := c.SeenMessagesTTL.WithDefault(DefaultSeenMessagesTTL); ttl <= 0 {
+		return fmt.Errorf("Pubsub.SeenMessagesTTL must be positive, got %s", ttl)
+	}
+	switch s := c.SeenMessagesStrategy.WithDefault(DefaultSeenMessagesStrategy); s {
+	case LastSeenMessagesStrategy, FirstSeenMessagesStrategy:
+	default:
+		return fmt.Errorf("unknown Pubsub.SeenMessagesStrategy %q, expected %s or %s", s, LastSeenMessagesStrategy, FirstSeenMessagesStrategy)
+	}
+	return nil
 }
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPubsubDefaults(t *testing.T) {
+	var c PubsubConfig
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.Gossipsub.Params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.D != 6 || p.Dlo != 5 || p.Dhi != 12 || p.HeartbeatInterval != time.Second ||
+		p.HistoryLength != 5 || p.HistoryGossip != 3 || !p.FloodPublish || p.PeerScoreThresholds != nil {
+		t.Fatalf("unexpected default gossipsub params %+v", p)
+	}
+	if ttl := c.SeenMessagesTTL.WithDefault(DefaultSeenMessagesTTL); ttl != 120*time.Second {
+		t.Fatalf("expected a 2m seen messages TTL, got %s", ttl)
+	}
+}
+
+func TestPubsubUnmarshal(t *testing.T) {
+	var c PubsubConfig
+	err := json.Unmarshal([]byte(`{
+		"Router": "gossipsub",
+		"SeenMessagesTTL": "10m",
+		"SeenMessagesStrategy": "first-seen",
+		"Gossipsub": {"D": 8, "Dhi": 16, "HeartbeatInterval": "700ms", "FloodPublish": false}
+	}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.Gossipsub.Params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.D != 8 || p.Dlo != 5 || p.Dhi != 16 || p.HeartbeatInterval != 700*time.Millisecond || p.FloodPublish {
+		t.Fatalf("unexpected gossipsub params %+v", p)
+	}
+}
+
+func TestPubsubValidate(t *testing.T) {
+	invalid := map[string]PubsubConfig{
+		"router":        {Router: "randomsub"},
+		"floodsub":      {Router: FloodsubRouter, Gossipsub: &GossipsubConfig{}},
+		"dlo above d":   {Gossipsub: &GossipsubConfig{Dlo: NewOptionalInteger(7)}},
+		"d above dhi":   {Gossipsub: &GossipsubConfig{D: NewOptionalInteger(13)}},
+		"zero dlo":      {Gossipsub: &GossipsubConfig{Dlo: NewOptionalInteger(0)}},
+		"heartbeat":     {Gossipsub: &GossipsubConfig{HeartbeatInterval: NewOptionalDuration(0)}},
+		"history":       {Gossipsub: &GossipsubConfig{HistoryGossip: NewOptionalInteger(6)}},
+		"ttl":           {SeenMessagesTTL: NewOptionalDuration(-time.Second)},
+		"strategy":      {SeenMessagesStrategy: NewOptionalString("never-seen")},
+		"gossip score":  {Gossipsub: &GossipsubConfig{PeerScoreThresholds: &GossipsubScoreThresholds{GossipThreshold: 1}}},
+		"publish score": {Gossipsub: &GossipsubConfig{PeerScoreThresholds: &GossipsubScoreThresholds{GossipThreshold: -10, PublishThreshold: -5}}},
+	}
+	for name, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	valid := PubsubConfig{
+		Router:    GossipsubRouter,
+		Gossipsub: &GossipsubConfig{PeerScoreThresholds: DefaultGossipsubScoreThresholds()},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&PubsubConfig{Router: FloodsubRouter}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DefaultRelayTransportEnabled is the default value for
+// Swarm.Transports.Network.Relay.
+const DefaultRelayTransportEnabled = true
+
+// DefaultRelayServiceEnabled is the default value for
+// Swarm.RelayService.Enabled.
+const DefaultRelayServiceEnabled = true
+
+// Defaults for the circuit v2 relay service resources. They match the libp2p
+// relay defaults and are used for every Swarm.RelayService field left unset.
+const (
+	DefaultRelayServiceConnectionDurationLimit = 2 * time.Minute
+	DefaultRelayServiceConnectionDataLimit     = 1 << 17 // 128 KiB
+	DefaultRelayServiceReservationTTL          = time.Hour
+	DefaultRelayServiceMaxReservations         = 128
+	DefaultRelayServiceMaxCircuits             = 16
+	DefaultRelayServiceBufferSize              = 2048
+	DefaultRelayServiceMaxReservationsPerPeer  = 4
+	DefaultRelayServiceMaxReservationsPerIP    = 8
+	DefaultRelayServiceMaxReservationsPerASN   = 32
+)
+
+// RelaySettings is the effective relay configuration of a node, computed from
+// both the current and the deprecated Swarm relay fields.
+type RelaySettings struct {
+	// Transport is true if the circuit relay transport is enabled.
+	Transport bool
+
+	// Client is true if the node should use relays when it is not publicly
+	// reachable.
+	Client bool
+	// StaticRelays are the parsed Swarm.RelayClient.StaticRelays, grouped
+	// by peer.
+	StaticRelays []peer.AddrInfo
+
+	// Service is true if the node provides the circuit v2 relay service.
+	Service bool
+	// ServiceLimits are the relay service resources with defaults filled in.
+	ServiceLimits RelayServiceLimits
+}
+
+// RelayServiceLimits is RelayService with every unset field resolved to its
+// default value.
+type RelayServiceLimits struct {
+	ConnectionDurationLimit time.Duration
+	ConnectionDataLimit     int64
+	ReservationTTL          time.Duration
+	MaxReservations         int64
+	MaxCircuits             int64
+	BufferSize              int64
+	MaxReservationsPerPeer  int64
+	MaxReservationsPerIP    int64
+	MaxReservationsPerASN   int64
+}
+
+// ResolveRelay computes the effective relay settings.
+//
+// The settings are resolved in the following order of precedence:
+//
+//   - Transport: Swarm.Transports.Network.Relay if set, otherwise the
+//     inverse of the deprecated Swarm.DisableRelay.
+//   - Client: Swarm.RelayClient.Enabled if set, otherwise true when the
+//     deprecated Swarm.EnableAutoRelay is set, otherwise
+//     DefaultEnableAutoRelay.
+//   - Service: Swarm.RelayService.Enabled if set, otherwise true when the
+//     deprecated Swarm.EnableRelayHop is set, otherwise
+//     DefaultRelayServiceEnabled.
+//
+// Both the client and the service need the relay transport. They are
+// silently turned off when the transport is disabled, unless they were
+// explicitly enabled, in which case an error is returned.
+func (s *SwarmConfig) ResolveRelay() (RelaySettings, error) {
+	var rs RelaySettings
+
+	rs.Transport = s.Transports.Network.Relay.WithDefault(DefaultRelayTransportEnabled && !s.DisableRelay)
+
+	rs.Client = s.RelayClient.Enabled.WithDefault(DefaultEnableAutoRelay || s.EnableAutoRelay)
+	if !rs.Transport {
+		if s.RelayClient.Enabled == True {
+			return rs, fmt.Errorf("Swarm.RelayClient.Enabled requires Swarm.Transports.Network.Relay to be enabled")
+		}
+		rs.Client = false
+	}
+
+	rs.Service = s.RelayService.Enabled.WithDefault(DefaultRelayServiceEnabled || s.EnableRelayHop)
+	if !rs.Transport {
+		if s.RelayService.Enabled == True {
+			return rs, fmt.Errorf("Swarm.RelayService.Enabled requires Swarm.Transports.Network.Relay to be enabled")
+		}
+		rs.Service = false
+	}
+
+	staticRelays, err := s.RelayClient.ParseStaticRelays()
+	if err != nil {
+		return rs, err
+	}
+	rs.StaticRelays = staticRelays
+
+	limits, err := s.RelayService.Limits()
+	if err != nil {
+		return rs, err
+	}
+	rs.ServiceLimits = limits
+
+	return rs, nil
+}
+
+// ParseStaticRelays parses StaticRelays into a list of AddrInfos. Every entry
+// must be a multiaddr ending in a /p2p/ component.
+func (rc *RelayClient) ParseStaticRelays() ([]peer.AddrInfo, error) {
+	maddrs := make([]ma.Multiaddr, len(rc.StaticRelays))
+	for i, s := range rc.StaticRelays {
+		maddr, err := ma.NewMultiaddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Swarm.RelayClient.StaticRelays entry %q: %s", s, err)
+		}
+		if _, err := peer.AddrInfoFromP2pAddr(maddr); err != nil {
+			return nil, fmt.Errorf("invalid Swarm.RelayClient.StaticRelays entry %q: %s", s, err)
+		}
+		maddrs[i] = maddr
+	}
+	return peer.AddrInfosFromP2pAddrs(maddrs...)
+}
+
+// Limits returns the relay service resources, using the default value for
+// every field that is not set.
+func (rs *RelayService) Limits() (RelayServiceLimits, error) {
+	l := RelayServiceLimits{
+		ConnectionDurationLimit: rs.ConnectionDurationLimit.WithDefault(DefaultRelayServiceConnectionDurationLimit),
+		ConnectionDataLimit:     rs.ConnectionDataLimit.WithDefault(DefaultRelayServiceConnectionDataLimit),
+		ReservationTTL:          rs.ReservationTTL.WithDefault(DefaultRelayServiceReservationTTL),
+		MaxReservations:         rs.MaxReservations.WithDefault(DefaultRelayServiceMaxReservations),
+		MaxCircuits:             rs.MaxCircuits.WithDefault(DefaultRelayServiceMaxCircuits),
+		BufferSize:              rs.BufferSize.WithDefault(DefaultRelayServiceBufferSize),
+		MaxReservationsPerPeer:  rs.MaxReservationsPerPeer.WithDefault(DefaultRelayServiceMaxReservationsPerPeer),
+		MaxReservationsPerIP:    rs.MaxReservationsPerIP.WithDefault(DefaultRelayServiceMaxReservationsPerIP),
+		MaxReservationsPerASN:   rs.MaxReservationsPerASN.WithDefault(DefaultRelayServiceMaxReservationsPerASN),
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"ConnectionDurationLimit", l.ConnectionDurationLimit},
+		{"ReservationTTL", l.ReservationTTL},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			return l, fmt.Errorf("Swarm.RelayService.%s must be positive, got %s", d.name, d.value)
+		}
+	}
+	integers := []struct {
+		name  string
+		value int64
+	}{
+		{"ConnectionDataLimit", l.ConnectionDataLimit},
+		{"MaxReservations", l.MaxReservations},
+		{"MaxCircuits", l.MaxCircuits},
+		{"BufferSize", l.BufferSize},
+		{"MaxReservationsPerPeer", l.MaxReservationsPerPeer},
+		{"MaxReservationsPerIP", l.MaxReservationsPerIP},
+		{"MaxReservationsPerASN", l.MaxReservationsPerASN},
+	}
+	for _, i := range integers {
+		if i.value <= 0 {
+			return l, fmt.Errorf("Swarm.RelayService.%s must be positive, got %d", i.name, i.value)
+		}
+	}
+	return l, nil
+}
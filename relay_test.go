@@ -0,0 +1,152 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveRelay(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		var s SwarmConfig
+		rs, err := s.ResolveRelay()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.Transport || !rs.Client || !rs.Service {
+			t.Fatalf("expected relay transport, client and service to be enabled, got %+v", rs)
+		}
+		if rs.ServiceLimits.ReservationTTL != DefaultRelayServiceReservationTTL {
+			t.Fatalf("expected default reservation TTL, got %s", rs.ServiceLimits.ReservationTTL)
+		}
+		if rs.ServiceLimits.MaxCircuits != DefaultRelayServiceMaxCircuits {
+			t.Fatalf("expected default max circuits, got %d", rs.ServiceLimits.MaxCircuits)
+		}
+	})
+
+	t.Run("deprecated DisableRelay", func(t *testing.T) {
+		s := SwarmConfig{DisableRelay: true}
+		rs, err := s.ResolveRelay()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rs.Transport || rs.Client || rs.Service {
+			t.Fatalf("expected relay to be disabled, got %+v", rs)
+		}
+
+		s.Transports.Network.Relay = True
+		rs, err = s.ResolveRelay()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rs.Transport {
+			t.Fatal("expected Transports.Network.Relay to take precedence over DisableRelay")
+		}
+	})
+
+	t.Run("explicit service without transport", func(t *testing.T) {
+		var s SwarmConfig
+		s.Transports.Network.Relay = False
+		s.RelayService.Enabled = True
+		if _, err := s.ResolveRelay(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("static relays", func(t *testing.T) {
+		s := SwarmConfig{RelayClient: RelayClient{StaticRelays: []string{
+			"/ip4/1.2.3.4/tcp/4001/p2p/16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5",
+			"/ip4/1.2.3.4/udp/4001/quic/p2p/16Uiu2HAmVeJwSMkeaEXEZdDAtxM6mngAALjTPwq4w2suehMVPwA5",
+		}}}
+		rs, err := s.ResolveRelay()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rs.StaticRelays) != 1 || len(rs.StaticRelays[0].Addrs) != 2 {
+			t.Fatalf("expected one relay with two addresses, got %v", rs.StaticRelays)
+		}
+
+		s.RelayClient.StaticRelays = []string{"/ip4/1.2.3.4/tcp/4001"}
+		if _, err := s.ResolveRelay(); err == nil {
+			t.Fatal("expected an error for a static relay without a peer ID")
+		}
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		var s SwarmConfig
+		s.RelayService.ReservationTTL = NewOptionalDuration(-time.Second)
+		if _, err := s.ResolveRelay(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestMigrateRelayFields(t *testing.T) {
+	cfg := &Config{}
+	cfg.Swarm.DisableRelay = true
+	cfg.Swarm.EnableRelayHop = true
+	cfg.Swarm.EnableAutoRelay = true
+	cfg.Swarm.RelayService.Enabled = False
+
+	if !migrate_19_RelayFields(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if cfg.Swarm.DisableRelay || cfg.Swarm.EnableRelayHop || cfg.Swarm.EnableAutoRelay {
+		t.Fatal("expected deprecated fields to be cleared")
+	}
+	if cfg.Swarm.Transports.Network.Relay != False {
+		t.Fatalf("expected relay transport to be disabled, got %s", cfg.Swarm.Transports.Network.Relay)
+	}
+	if cfg.Swarm.RelayService.Enabled != False {
+		t.Fatal("expected explicit RelayService.Enabled to be preserved")
+	}
+	if cfg.Swarm.RelayClient.Enabled != Default {
+		t.Fatalf("expected relay client to stay unset without the relay transport, got %s", cfg.Swarm.RelayClient.Enabled)
+	}
+	if _, err := cfg.Swarm.ResolveRelay(); err != nil {
+		t.Fatal(err)
+	}
+	if migrate_19_RelayFields(cfg) {
+		t.Fatal("expected the migration to be idempotent")
+	}
+
+	cfg = &Config{}
+	cfg.Swarm.EnableRelayHop = true
+	cfg.Swarm.EnableAutoRelay = true
+	if !migrate_19_RelayFields(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if cfg.Swarm.RelayService.Enabled != True || cfg.Swarm.RelayClient.Enabled != True {
+		t.Fatalf("expected relay service and client to be enabled, got %s and %s", cfg.Swarm.RelayService.Enabled, cfg.Swarm.RelayClient.Enabled)
+	}
+}
+
+func TestMigrateConfigDisableRelay(t *testing.T) {
+	cfg := &Config{}
+	cfg.Swarm.SwarmKey = DefaultSwarmKey
+	cfg.Swarm.DisableRelay = true
+	cfg.Swarm.EnableAutoRelay = true
+	MigrateConfig(cfg, false, false)
+
+	if cfg.Swarm.Transports.Network.Relay != False || cfg.Swarm.RelayClient.Enabled == True {
+		t.Fatalf("expected the relay to stay disabled, got transport %s and client %s", cfg.Swarm.Transports.Network.Relay, cfg.Swarm.RelayClient.Enabled)
+	}
+	if _, err := cfg.Swarm.ResolveRelay(); err != nil {
+		t.Fatal(err)
+	}
+	if migrate_6_EnableAutoRelay(cfg) || migrate_19_RelayFields(cfg) {
+		t.Fatal("expected relay migrations to settle after the first run")
+	}
+}
+
+func TestMigrateConfigRelayStable(t *testing.T) {
+	cfg := &Config{}
+	cfg.Swarm.SwarmKey = DefaultSwarmKey
+	MigrateConfig(cfg, false, false)
+
+	if migrate_6_EnableAutoRelay(cfg) || migrate_19_RelayFields(cfg) {
+		t.Fatal("expected relay migrations to settle after the first run")
+	}
+	if cfg.Swarm.RelayClient.Enabled != True {
+		t.Fatalf("expected relay client to be enabled, got %s", cfg.Swarm.RelayClient.Enabled)
+	}
+}
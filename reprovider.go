@@ -5,6 +5,12 @@ import "time"
 const DefaultReproviderInterval = time.Hour * 22 // https://github.com/ipfs/kubo/pull/9326
 const DefaultReproviderStrategy = "all"
 
+const DefaultGCPeriod = time.Hour * 1
+const DefaultIpnsRepublishPeriod = time.Hour * 4
+const DefaultIpnsRecordLifetime = time.Hour * 24
+const MinIpnsRepublishPeriod = time.Minute * 1 // bounds of Ipns.RepublishPeriod
+const MaxIpnsRepublishPeriod = time.Hour * 24
+
 type Reprovider struct {
 	Interval *OptionalDuration `json:",omitempty"` // Time period to reprovide locally stored objects to the network
 	Strategy *OptionalString   `json:",omitempty"` // Which keys to announce
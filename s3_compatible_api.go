@@ -1,7 +1,198 @@
 package config
 
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Defaults for the S3-compatible API limits. They follow the limits of
+// Amazon S3 for single uploads and multipart uploads.
+const (
+	DefaultS3CompatibleAPIRegion               = "us-east-1"
+	DefaultS3CompatibleAPIMaxObjectSize        = 5 << 30 // 5 GiB
+	DefaultS3CompatibleAPIMultipartMaxParts    = 10000
+	DefaultS3CompatibleAPIMultipartMinPartSize = 5 << 20 // 5 MiB
+)
+
 type S3CompatibleAPI struct {
 	Enable      bool
 	Address     string
 	HTTPHeaders map[string][]string // Leave nil for default headers
+
+	// CORS configures the Access-Control-* headers of the S3-compatible API.
+	// It replaces the corresponding entries of HTTPHeaders.
+	CORS *CORS `json:",omitempty"`
+
+	// TLS enables HTTPS on the S3-compatible API listener when set.
+	TLS *TLS `json:",omitempty"`
+
+	// Region is the region reported to S3 clients and used in request
+	// signatures.
+	Region string
+
+	// AccessKeys are the credentials accepted by the S3-compatible API.
+	AccessKeys []S3AccessKey `json:",omitempty"`
+
+	// AllowedBuckets restricts the bucket names that can be used. Any
+	// valid bucket name is allowed when empty.
+	AllowedBuckets []string `json:",omitempty"`
+
+	MaxObjectSize        int64 // in bytes
+	MultipartMaxParts    int
+	MultipartMinPartSize int64 // in bytes, except for the last part
+}
+
+// S3AccessKey is an access key/secret pair of the S3-compatible API and the
+// permissions granted to it.
+type S3AccessKey struct {
+	AccessKeyID string
+	// Secret is the secret access key. It is stored unencrypted, like
+	// Identity.PrivKey.
+	Secret string
+
+	Read  bool
+	Write bool
+
+	// Buckets restricts the key to the given buckets. The key can access
+	// every allowed bucket when empty.
+	Buckets []string `json:",omitempty"`
+}
+
+// NewS3AccessKey generates a random access key/secret pair with the given
+// permissions.
+func NewS3AccessKey(read, write bool) (S3AccessKey, error) {
+	const idChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	buf := make([]byte, 20+30)
+	if _, err := rand.Read(buf); err != nil {
+		return S3AccessKey{}, err
+	}
+	id := make([]byte, 20)
+	for i := range id {
+		id[i] = idChars[int(buf[i])%len(idChars)]
+	}
+
+	return S3AccessKey{
+		AccessKeyID: string(id),
+		Secret:      base64.RawURLEncoding.EncodeToString(buf[20:]),
+		Read:        read,
+		Write:       write,
+	}, nil
+}
+
+// Allows reports whether the key can read or write the given bucket.
+func (k *S3AccessKey) Allows(bucket string, write bool) bool {
+	if write && !k.Write || !write && !k.Read {
+		return false
+	}
+	return len(k.Buckets) == 0 || containsString(k.Buckets, bucket)
+}
+
+// AccessKey returns the access key with the given ID.
+func (s *S3CompatibleAPI) AccessKey(id string) (*S3AccessKey, bool) {
+	for i := range s.AccessKeys {
+		if s.AccessKeys[i].AccessKeyID == id {
+			return &s.AccessKeys[i], true
+		}
+	}
+	return nil, false
+}
+
+// BucketAllowed reports whether the bucket name can be used.
+func (s *S3CompatibleAPI) BucketAllowed(bucket string) bool {
+	if validateS3BucketName(bucket) != nil {
+		return false
+	}
+	return len(s.AllowedBuckets) == 0 || containsString(s.AllowedBuckets, bucket)
+}
+
+// Headers returns the HTTP headers to return with the S3-compatible API,
+// combining HTTPHeaders and the CORS policy.
+func (s *S3CompatibleAPI) Headers() (map[string][]string, error) {
+	return mergeCORSHeaders("S3CompatibleAPI", s.HTTPHeaders, s.CORS)
+}
+
+// Validate checks the HTTP headers and the CORS policy of the S3-compatible
+// API, its credentials, buckets and limits. An enabled API must have at
+// least one access key.
+func (s *S3CompatibleAPI) Validate() error {
+	if err := validateCORSConfig("S3CompatibleAPI", s.HTTPHeaders, s.CORS); err != nil {
+		return err
+	}
+
+	if s.Enable && len(s.AccessKeys) == 0 {
+		return fmt.Errorf("S3CompatibleAPI is enabled but has no AccessKeys")
+	}
+	if s.Region == "" || strings.Trim(s.Region, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+		return fmt.Errorf("invalid S3CompatibleAPI.Region %q", s.Region)
+	}
+	for _, b := range s.AllowedBuckets {
+		if err := validateS3BucketName(b); err != nil {
+			return fmt.Errorf("S3CompatibleAPI.AllowedBuckets: %s", err)
+		}
+	}
+
+	seen := make(map[string]bool, len(s.AccessKeys))
+	for _, k := range s.AccessKeys {
+		if len(k.AccessKeyID) < 16 || len(k.AccessKeyID) > 128 || strings.Trim(k.AccessKeyID, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789") != "" {
+			return fmt.Errorf("invalid S3CompatibleAPI access key ID %q: must be 16 to 128 alphanumeric characters", k.AccessKeyID)
+		}
+		if seen[k.AccessKeyID] {
+			return fmt.Errorf("duplicate S3CompatibleAPI access key ID %q", k.AccessKeyID)
+		}
+		seen[k.AccessKeyID] = true
+
+		if len(k.Secret) < 16 {
+			return fmt.Errorf("secret for S3CompatibleAPI access key %s is too short", k.AccessKeyID)
+		}
+		if !k.Read && !k.Write {
+			return fmt.Errorf("S3CompatibleAPI access key %s has neither Read nor Write permission", k.AccessKeyID)
+		}
+		for _, b := range k.Buckets {
+			if !s.BucketAllowed(b) {
+				return fmt.Errorf("S3CompatibleAPI access key %s refers to bucket %q which is not allowed", k.AccessKeyID, b)
+			}
+		}
+	}
+
+	if s.MaxObjectSize <= 0 {
+		return fmt.Errorf("S3CompatibleAPI.MaxObjectSize must be positive")
+	}
+	if s.MultipartMaxParts <= 0 || s.MultipartMaxParts > DefaultS3CompatibleAPIMultipartMaxParts {
+		return fmt.Errorf("S3CompatibleAPI.MultipartMaxParts must be between 1 and %d", DefaultS3CompatibleAPIMultipartMaxParts)
+	}
+	if s.MultipartMinPartSize <= 0 || s.MultipartMinPartSize > s.MaxObjectSize {
+		return fmt.Errorf("S3CompatibleAPI.MultipartMinPartSize must be positive and not larger than MaxObjectSize")
+	}
+	return nil
+}
+
+// validateS3BucketName checks a bucket name against the S3 naming rules.
+func validateS3BucketName(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("invalid bucket name %q: must be 3 to 63 characters long", name)
+	}
+	if strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789.-") != "" {
+		return fmt.Errorf("invalid bucket name %q: only lower-case letters, digits, dots and hyphens are allowed", name)
+	}
+	first, last := name[0], name[len(name)-1]
+	if first == '.' || first == '-' || last == '.' || last == '-' || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid bucket name %q", name)
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("invalid bucket name %q: must not be formatted as an IP address", name)
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
 }
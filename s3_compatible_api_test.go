@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestS3CompatibleAPIAccessKeys(t *testing.T) {
+	key, err := NewS3AccessKey(true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.AccessKeyID) != 20 || len(key.Secret) != 40 {
+		t.Fatalf("unexpected key %q with secret %q", key.AccessKeyID, key.Secret)
+	}
+
+	s := DefaultS3CompatibleAPIConfig()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Enable = true
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected an enabled API without keys to be rejected")
+	}
+
+	key.Buckets = []string{"photos"}
+	s.AccessKeys = []S3AccessKey{key}
+	s.AllowedBuckets = []string{"photos", "backups"}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	k, ok := s.AccessKey(key.AccessKeyID)
+	if !ok {
+		t.Fatal("expected to find the access key")
+	}
+	if !k.Allows("photos", false) || k.Allows("photos", true) || k.Allows("backups", false) {
+		t.Fatal("unexpected permissions")
+	}
+	if !s.BucketAllowed("backups") || s.BucketAllowed("music") || s.BucketAllowed("192.168.1.1") {
+		t.Fatal("unexpected allowed buckets")
+	}
+
+	s.AccessKeys[0].Buckets = []string{"music"}
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected a key referring to a disallowed bucket to be rejected")
+	}
+	s.AccessKeys[0].Buckets = nil
+
+	s.AccessKeys = append(s.AccessKeys, key)
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected duplicate keys to be rejected")
+	}
+}
+
+func TestMigrateS3CompatibleAPILimits(t *testing.T) {
+	cfg := &Config{}
+	if !migrate_18_S3CompatibleAPI(cfg) || !migrate_20_S3CompatibleAPILimits(cfg) {
+		t.Fatal("expected the config to be updated")
+	}
+	if err := cfg.S3CompatibleAPI.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if migrate_20_S3CompatibleAPILimits(cfg) {
+		t.Fatal("expected the migration to be idempotent")
+	}
+}
@@ -1,5 +1,19 @@
 package config
 
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
 type Services struct {
 	//StatusServerDomain string
 	OnlineServerDomain string
@@ -13,4 +27,284 @@ type Services struct {
 
 	EscrowPubKeys []string
 	GuardPubKeys  []string
+
+	// Fallbacks lists additional endpoints per service, tried in order when
+	// the primary endpoint is unavailable.
+	Fallbacks map[ServiceName][]string `json:",omitempty"`
+}
+
+// ServiceName identifies an external service configured in Services.
+type ServiceName string
+
+const (
+	ServiceOnlineServer ServiceName = "OnlineServer"
+	ServiceHub          ServiceName = "Hub"
+	ServiceEscrow       ServiceName = "Escrow"
+	ServiceGuard        ServiceName = "Guard"
+	ServiceExchange     ServiceName = "Exchange"
+	ServiceSolidity     ServiceName = "Solidity"
+	ServiceFullnode     ServiceName = "Fullnode"
+	ServiceTrongrid     ServiceName = "Trongrid"
+)
+
+// EndpointKind is the format expected for the endpoints of a service.
+type EndpointKind int
+
+const (
+	// EndpointURL is an http(s):// URL.
+	EndpointURL EndpointKind = iota
+	// EndpointGRPC is a host:port gRPC target, without a scheme.
+	EndpointGRPC
+)
+
+func (k EndpointKind) String() string {
+	switch k {
+	case EndpointURL:
+		return "url"
+	case EndpointGRPC:
+		return "grpc"
+	default:
+		return fmt.Sprintf("<invalid endpoint kind %d>", int(k))
+	}
+}
+
+// ServiceSpec describes an external service and where its primary endpoint
+// is stored in Services.
+type ServiceSpec struct {
+	Name ServiceName
+	Kind EndpointKind
+
+	domain func(s *Services) *string
+}
+
+// Field returns the name of the Services field holding the primary endpoint.
+func (ss ServiceSpec) Field() string {
+	return string(ss.Name) + "Domain"
+}
+
+// ServiceRegistry lists the external services known to a node.
+var ServiceRegistry = []ServiceSpec{
+	{ServiceOnlineServer, EndpointURL, func(s *Services) *string { return &s.OnlineServerDomain }},
+	{ServiceHub, EndpointURL, func(s *Services) *string { return &s.HubDomain }},
+	{ServiceEscrow, EndpointURL, func(s *Services) *string { return &s.EscrowDomain }},
+	{ServiceGuard, EndpointURL, func(s *Services) *string { return &s.GuardDomain }},
+	{ServiceExchange, EndpointURL, func(s *Services) *string { return &s.ExchangeDomain }},
+	{ServiceSolidity, EndpointGRPC, func(s *Services) *string { return &s.SolidityDomain }},
+	{ServiceFullnode, EndpointGRPC, func(s *Services) *string { return &s.FullnodeDomain }},
+	{ServiceTrongrid, EndpointURL, func(s *Services) *string { return &s.TrongridDomain }},
+}
+
+// LookupService returns the spec of a service by name. Both the service name
+// ("Hub") and the Services field name ("HubDomain") are accepted, ignoring
+// case.
+func LookupService(name string) (ServiceSpec, bool) {
+	for _, ss := range ServiceRegistry {
+		if strings.EqualFold(name, string(ss.Name)) || strings.EqualFold(name, ss.Field()) {
+			return ss, true
+		}
+	}
+	return ServiceSpec{}, false
+}
+
+// Endpoint returns the primary endpoint of a service.
+func (s *Services) Endpoint(name ServiceName) (string, error) {
+	ss, ok := LookupService(string(name))
+	if !ok {
+		return "", fmt.Errorf("unknown service %q", name)
+	}
+	return *ss.domain(s), nil
+}
+
+// Endpoints returns the primary endpoint of a service followed by its
+// fallbacks.
+func (s *Services) Endpoints(name ServiceName) ([]string, error) {
+	ss, ok := LookupService(string(name))
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", name)
+	}
+	var eps []string
+	if primary := *ss.domain(s); primary != "" {
+		eps = append(eps, primary)
+	}
+	eps = append(eps, s.Fallbacks[ss.Name]...)
+	if len(eps) == 0 {
+		return nil, fmt.Errorf("no endpoint configured for service %s", ss.Name)
+	}
+	return eps, nil
+}
+
+// SetEndpoint sets the primary endpoint of a service.
+func (s *Services) SetEndpoint(name ServiceName, endpoint string) error {
+	ss, ok := LookupService(string(name))
+	if !ok {
+		return fmt.Errorf("unknown service %q", name)
+	}
+	if err := ss.ValidateEndpoint(endpoint); err != nil {
+		return err
+	}
+	*ss.domain(s) = endpoint
+	return nil
+}
+
+// Validate checks that every configured endpoint has the format expected by
+// its service.
+func (s *Services) Validate() error {
+	for _, ss := range ServiceRegistry {
+		primary := *ss.domain(s)
+		if primary == "" {
+			if len(s.Fallbacks[ss.Name]) > 0 {
+				return fmt.Errorf("Services.%s is empty but has fallbacks", ss.Field())
+			}
+			continue
+		}
+		if err := ss.ValidateEndpoint(primary); err != nil {
+			return fmt.Errorf("Services.%s: %s", ss.Field(), err)
+		}
+		for _, fb := range s.Fallbacks[ss.Name] {
+			if err := ss.ValidateEndpoint(fb); err != nil {
+				return fmt.Errorf("Services.Fallbacks[%s]: %s", ss.Name, err)
+			}
+		}
+	}
+	for name := range s.Fallbacks {
+		if ss, ok := LookupService(string(name)); !ok || ss.Name != name {
+			return fmt.Errorf("Services.Fallbacks: unknown service %q", name)
+		}
+	}
+	if _, err := s.EscrowPublicKeys(); err != nil {
+		return err
+	}
+	if _, err := s.GuardPublicKeys(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateEndpoint checks that endpoint has the format expected by the
+// service.
+func (ss ServiceSpec) ValidateEndpoint(endpoint string) error {
+	switch ss.Kind {
+	case EndpointURL:
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid %s endpoint %q: %s", ss.Name, endpoint, err)
+		}
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return fmt.Errorf("invalid %s endpoint %q: expected an https:// URL", ss.Name, endpoint)
+		}
+		if u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid %s endpoint %q: expected an https:// URL", ss.Name, endpoint)
+		}
+	case EndpointGRPC:
+		if strings.Contains(endpoint, "://") {
+			return fmt.Errorf("invalid %s endpoint %q: expected a host:port gRPC target without a scheme", ss.Name, endpoint)
+		}
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil || host == "" {
+			return fmt.Errorf("invalid %s endpoint %q: expected a host:port gRPC target", ss.Name, endpoint)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return fmt.Errorf("invalid %s endpoint %q: invalid port", ss.Name, endpoint)
+		}
+	default:
+		return fmt.Errorf("invalid endpoint kind %s for service %s", ss.Kind, ss.Name)
+	}
+	return nil
+}
+
+// ErrSignatureNotVerified is returned when a signature does not match any of
+// the configured service keys.
+var ErrSignatureNotVerified = errors.New("signature does not match any configured key")
+
+// ServicePubKeyType is the type of the escrow and guard public keys.
+const ServicePubKeyType = pb.KeyType_Secp256k1
+
+// EscrowPublicKeys decodes EscrowPubKeys.
+func (s *Services) EscrowPublicKeys() ([]ic.PubKey, error) {
+	return decodeServicePubKeys("EscrowPubKeys", s.EscrowPubKeys)
+}
+
+// GuardPublicKeys decodes GuardPubKeys.
+func (s *Services) GuardPublicKeys() ([]ic.PubKey, error) {
+	return decodeServicePubKeys("GuardPubKeys", s.GuardPubKeys)
+}
+
+// EscrowPeerIDs returns the peer IDs derived from EscrowPubKeys.
+func (s *Services) EscrowPeerIDs() ([]peer.ID, error) {
+	pks, err := s.EscrowPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	return peerIDsFromPubKeys(pks)
+}
+
+// GuardPeerIDs returns the peer IDs derived from GuardPubKeys.
+func (s *Services) GuardPeerIDs() ([]peer.ID, error) {
+	pks, err := s.GuardPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	return peerIDsFromPubKeys(pks)
+}
+
+// VerifyEscrowSignature checks sig against every escrow key and returns the
+// peer ID of the key that signed data.
+func (s *Services) VerifyEscrowSignature(data, sig []byte) (peer.ID, error) {
+	pks, err := s.EscrowPublicKeys()
+	if err != nil {
+		return "", err
+	}
+	return verifyServiceSignature(pks, data, sig)
+}
+
+// VerifyGuardSignature checks sig against every guard key and returns the
+// peer ID of the key that signed data.
+func (s *Services) VerifyGuardSignature(data, sig []byte) (peer.ID, error) {
+	pks, err := s.GuardPublicKeys()
+	if err != nil {
+		return "", err
+	}
+	return verifyServiceSignature(pks, data, sig)
+}
+
+func decodeServicePubKeys(field string, keys []string) ([]ic.PubKey, error) {
+	pks := make([]ic.PubKey, 0, len(keys))
+	for i, k := range keys {
+		b, err := base64.StdEncoding.DecodeString(k)
+		if err != nil {
+			return nil, fmt.Errorf("Services.%s[%d] is not valid base64: %s", field, i, err)
+		}
+		pk, err := ic.UnmarshalPublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("Services.%s[%d] is not a valid public key: %s", field, i, err)
+		}
+		if pk.Type() != ServicePubKeyType {
+			return nil, fmt.Errorf("Services.%s[%d] is a %s key, expected %s", field, i, pk.Type(), ServicePubKeyType)
+		}
+		pks = append(pks, pk)
+	}
+	return pks, nil
+}
+
+func peerIDsFromPubKeys(pks []ic.PubKey) ([]peer.ID, error) {
+	ids := make([]peer.ID, 0, len(pks))
+	for _, pk := range pks {
+		id, err := peer.IDFromPublicKey(pk)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func verifyServiceSignature(pks []ic.PubKey, data, sig []byte) (peer.ID, error) {
+	for _, pk := range pks {
+		ok, err := pk.Verify(data, sig)
+		if err != nil || !ok {
+			continue
+		}
+		return peer.IDFromPublicKey(pk)
+	}
+	return "", ErrSignatureNotVerified
 }
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestServicesValidate(t *testing.T) {
+	for _, s := range []Services{DefaultServicesConfig(), DefaultServicesConfigDev(), DefaultServicesConfigTestnet()} {
+		if err := s.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := DefaultServicesConfig()
+	s.SolidityDomain = "https://grpc.trongrid.io:50052"
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected a URL to be rejected for a gRPC service")
+	}
+
+	s = DefaultServicesConfig()
+	s.HubDomain = "score.btfs.io:443"
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected a gRPC target to be rejected for a URL service")
+	}
+
+	s = DefaultServicesConfig()
+	s.Fallbacks = map[ServiceName][]string{"Nope": {"https://example.com"}}
+	if err := s.Validate(); err == nil {
+		t.Fatal("expected an unknown fallback service to be rejected")
+	}
+}
+
+func TestServicesEndpoints(t *testing.T) {
+	s := DefaultServicesConfig()
+	s.Fallbacks = map[ServiceName][]string{
+		ServiceHub:      {"https://hub.example.com"},
+		ServiceFullnode: {"fullnode.example.com:50051"},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	eps, err := s.Endpoints(ServiceHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 2 || eps[0] != s.HubDomain || eps[1] != "https://hub.example.com" {
+		t.Fatalf("unexpected endpoints %v", eps)
+	}
+
+	ss, ok := LookupService("fullnodedomain")
+	if !ok || ss.Name != ServiceFullnode || ss.Kind != EndpointGRPC {
+		t.Fatalf("unexpected lookup result %+v", ss)
+	}
+
+	if err := s.SetEndpoint(ServiceEscrow, "http://localhost:8000"); err != nil {
+		t.Fatal(err)
+	}
+	if ep, _ := s.Endpoint(ServiceEscrow); ep != "http://localhost:8000" {
+		t.Fatalf("unexpected escrow endpoint %s", ep)
+	}
+	if err := s.SetEndpoint(ServiceSolidity, "localhost"); err == nil {
+		t.Fatal("expected a gRPC target without a port to be rejected")
+	}
+	if _, err := s.Endpoints("Status"); err == nil {
+		t.Fatal("expected an unknown service to be rejected")
+	}
+}
+
+func TestServicesPubKeys(t *testing.T) {
+	s := DefaultServicesConfig()
+	ids, err := s.EscrowPeerIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected one escrow peer ID, got %v", ids)
+	}
+
+	sk, pk, err := ic.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkb, err := ic.MarshalPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.GuardPubKeys = append(s.GuardPubKeys, base64.StdEncoding.EncodeToString(pkb))
+
+	data := []byte("contract")
+	sig, err := sk.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.VerifyGuardSignature(data, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := peer.IDFromPublicKey(pk); id != expected {
+		t.Fatalf("expected %s, got %s", expected, id)
+	}
+	if _, err := s.VerifyEscrowSignature(data, sig); err != ErrSignatureNotVerified {
+		t.Fatalf("expected ErrSignatureNotVerified, got %v", err)
+	}
+
+	_, edPk, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPkb, err := ic.MarshalPublicKey(edPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"not base64!", "CAISIQ==", base64.StdEncoding.EncodeToString(edPkb)} {
+		s := DefaultServicesConfig()
+		s.EscrowPubKeys = []string{bad}
+		if err := s.Validate(); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StorageFreeRatio is the share of a filesystem's free space that
+// PlanStorage recommends for Datastore.StorageMax. The rest is left for the
+// filesystem and for the datastore's own overhead.
+const StorageFreeRatio = 0.9
+
+// MinStorageFree is the free space below which PlanStorage warns about a
+// datastore mount.
+const MinStorageFree = 1 * GB
+
+// StorageMount is a datastore mount and the filesystem it is stored on.
+type StorageMount struct {
+	Mountpoint string // datastore mountpoint, e.g. /blocks
+	Type       string // datastore type, e.g. flatfs
+	Path       string // absolute directory of the datastore
+	Total      ByteSize
+	Free       ByteSize // available to the node, excluding data already stored
+}
+
+// StoragePlan compares the datastore configuration with the disks it is
+// stored on.
+type StoragePlan struct {
+	Mounts      []StorageMount
+	StorageMax  ByteSize
+	GCThreshold ByteSize // the usage at which GC starts
+	// Recommended is the largest StorageMax, rounded down to whole GB, that
+	// fits the free space of the filesystem storing blocks.
+	Recommended ByteSize
+	Warnings    []string
+}
+
+// PlanStorage inspects the filesystem of every datastore mount in ds.Spec,
+// with relative paths resolved against DataStorePath(configroot), and checks
+// StorageMax and StorageGCWatermark against their capacity and free space.
+//
+// Blocks are stored in the mount at /blocks, or the root mount if there is
+// none, so that mount determines the recommendation. Data already in the
+// datastore is not counted as free, so the plan is most accurate at init.
+func PlanStorage(configroot string, ds *Datastore) (*StoragePlan, error) {
+	root, err := DataStorePath(configroot)
+	if err != nil {
+		return nil, err
+	}
+	p := &StoragePlan{
+		StorageMax:  ds.StorageMax,
+		GCThreshold: ds.StorageMax / 100 * ByteSize(ds.StorageGCWatermark),
+	}
+	var blocks *StorageMount
+	for _, m := range datastoreMounts(ds.Spec, "/") {
+		if !filepath.IsAbs(m.Path) {
+			m.Path = filepath.Join(root, m.Path)
+		}
+		if m.Total, m.Free, err = diskUsage(existingAncestor(m.Path)); err != nil {
+			return nil, fmt.Errorf("datastore mount %s: %s", m.Mountpoint, err)
+		}
+		p.Mounts = append(p.Mounts, m)
+	}
+	if len(p.Mounts) == 0 {
+		return nil, errors.New("Datastore.Spec has no datastore with a path")
+	}
+	for i := range p.Mounts {
+		m := &p.Mounts[i]
+		if m.Mountpoint == "/blocks" || (m.Mountpoint == "/" && blocks == nil) {
+			blocks = m
+		}
+		if m.Free < MinStorageFree {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("datastore mount %s at %s has only %s free", m.Mountpoint, m.Path, m.Free))
+		}
+	}
+	if blocks == nil {
+		blocks = &p.Mounts[0]
+	}
+	p.Recommended = ByteSize(float64(blocks.Free)*StorageFreeRatio) / GB * GB
+
+	switch {
+	case ds.StorageMax == 0:
+		p.Warnings = append(p.Warnings, "Datastore.StorageMax is not set")
+	case ds.StorageMax > blocks.Total:
+		p.Warnings = append(p.Warnings, fmt.Sprintf("Datastore.StorageMax %s exceeds the %s disk at %s, recommended %s", ds.StorageMax, blocks.Total, blocks.Path, p.Recommended))
+	case ds.StorageMax > p.Recommended:
+		p.Warnings = append(p.Warnings, fmt.Sprintf("Datastore.StorageMax %s exceeds the %s free at %s, recommended %s", ds.StorageMax, blocks.Free, blocks.Path, p.Recommended))
+	}
+	if ds.StorageGCWatermark <= 0 || ds.StorageGCWatermark > 100 {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("Datastore.StorageGCWatermark must be between 1 and 100, got %d", ds.StorageGCWatermark))
+	} else if p.GCThreshold > blocks.Free {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("garbage collection starts at %s, above the %s free at %s", p.GCThreshold, blocks.Free, blocks.Path))
+	}
+	return p, nil
+}
+
+// autosizeStorage sets Datastore.StorageMax to the Recommended value of
+// PlanStorage for the repo at configroot, and marks it with AutoStorageMax
+// so that the storage host profiles keep it.
+func autosizeStorage(c *Config, configroot string) error {
+	plan, err := PlanStorage(configroot, &c.Datastore)
+	if err != nil {
+		return err
+	}
+	if plan.Recommended == 0 {
+		return fmt.Errorf("not enough free space for a datastore: %s", strings.Join(plan.Warnings, "; "))
+	}
+	c.Datastore.StorageMax = plan.Recommended
+	c.Datastore.AutoStorageMax = true
+	return nil
+}
+
+// datastoreMounts returns the datastores with a path in a spec, following
+// mount and wrapper (child) datastores.
+func datastoreMounts(spec map[string]interface{}, mountpoint string) []StorageMount {
+	if mp, ok := spec["mountpoint"].(string); ok {
+		mountpoint = mp
+	}
+	if path, ok := spec["path"].(string); ok {
+		t, _ := spec["type"].(string)
+		return []StorageMount{{Mountpoint: mountpoint, Type: t, Path: path}}
+	}
+	if child, ok := spec["child"].(map[string]interface{}); ok {
+		return datastoreMounts(child, mountpoint)
+	}
+	var out []StorageMount
+	mounts, _ := spec["mounts"].([]interface{})
+	for _, m := range mounts {
+		if m, ok := m.(map[string]interface{}); ok {
+			out = append(out, datastoreMounts(m, mountpoint)...)
+		}
+	}
+	return out
+}
+
+// existingAncestor returns the closest existing directory to path, so that
+// disk usage can be measured before the repo is created.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
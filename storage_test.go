@@ -0,0 +1,94 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDatastoreMounts(t *testing.T) {
+	mounts := datastoreMounts(flatfsSpec(), "/")
+	if len(mounts) != 2 {
+		t.Fatalf("expected two mounts, got %v", mounts)
+	}
+	if mounts[0] != (StorageMount{Mountpoint: "/blocks", Type: "flatfs", Path: "blocks"}) {
+		t.Fatalf("unexpected blocks mount %+v", mounts[0])
+	}
+	if mounts[1] != (StorageMount{Mountpoint: "/", Type: "levelds", Path: "datastore"}) {
+		t.Fatalf("unexpected root mount %+v", mounts[1])
+	}
+	if mounts := datastoreMounts(badgerSpec(), "/"); len(mounts) != 1 || mounts[0].Type != "badgerds" {
+		t.Fatalf("unexpected badger mounts %v", mounts)
+	}
+}
+
+func TestPlanStorage(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "repo")
+	ds := DefaultDatastoreConfig()
+	plan, err := PlanStorage(root, &ds)
+	if err != nil {
+		t.Skipf("disk usage unavailable: %s", err)
+	}
+	if len(plan.Mounts) != 2 || plan.Mounts[0].Path != filepath.Join(root, "datastore", "blocks") {
+		t.Fatalf("unexpected mounts %+v", plan.Mounts)
+	}
+	if plan.Mounts[0].Total == 0 || plan.Recommended > plan.Mounts[0].Free || plan.Recommended%GB != 0 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	if plan.GCThreshold != 9*GB {
+		t.Fatalf("expected GC to start at 9GB, got %s", plan.GCThreshold)
+	}
+
+	ds.StorageMax = plan.Mounts[0].Total + TB
+	ds.StorageGCWatermark = 0
+	plan, err = PlanStorage(root, &ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := strings.Join(plan.Warnings, "\n")
+	for _, want := range []string{"exceeds the", "StorageGCWatermark"} {
+		if !strings.Contains(warnings, want) {
+			t.Fatalf("expected a warning containing %q, got %q", want, warnings)
+		}
+	}
+
+	ds.Spec = map[string]interface{}{"type": "mem"}
+	if _, err := PlanStorage(root, &ds); err == nil {
+		t.Fatal("expected an error for a spec without paths")
+	}
+}
+
+func TestStorageAutosizeProfile(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "repo")
+	c := &Config{Datastore: DefaultDatastoreConfig()}
+	plan, err := PlanStorage(root, &c.Datastore)
+	if err != nil {
+		t.Skipf("disk usage unavailable: %s", err)
+	}
+	if plan.Recommended == 0 {
+		t.Skip("not enough free space")
+	}
+	if err := Profiles["storage-autosize"].Apply(c, root); err != nil {
+		t.Fatal(err)
+	}
+	if c.Datastore.StorageMax != plan.Recommended || !c.Datastore.AutoStorageMax {
+		t.Fatalf("expected StorageMax %s, got %s", plan.Recommended, c.Datastore.StorageMax)
+	}
+
+	// a recommendation of exactly the default is kept too.
+	c.Datastore.StorageMax = DefaultStorageMax
+	if err := Profiles["storage-host"].Transform(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Datastore.StorageMax != DefaultStorageMax {
+		t.Fatalf("expected storage-host to keep the autosized StorageMax, got %s", c.Datastore.StorageMax)
+	}
+
+	c.Datastore.AutoStorageMax = false
+	if err := Profiles["storage-host"].Transform(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Datastore.StorageMax != DefaultHostStorageMax {
+		t.Fatalf("expected storage-host to raise the default StorageMax, got %s", c.Datastore.StorageMax)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultTLSMinVersion is the default value for TLS.MinVersion.
+const DefaultTLSMinVersion = "1.2"
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS configures HTTPS for one of the HTTP listeners (API, Gateway and
+// S3CompatibleAPI). Relative file paths are resolved against the config
+// root.
+type TLS struct {
+	// CertFile is the PEM-encoded certificate chain of the listener.
+	CertFile string
+	// KeyFile is the PEM-encoded private key of the certificate.
+	KeyFile string
+
+	// ClientCAFile is a PEM bundle of certificate authorities. When set,
+	// clients must present a certificate signed by one of them (mutual TLS).
+	ClientCAFile string `json:",omitempty"`
+
+	// MinVersion is the minimum accepted TLS version, "1.2" or "1.3".
+	MinVersion *OptionalString `json:",omitempty"`
+
+	// CipherSuites restricts the TLS 1.2 cipher suites to the given IANA
+	// names, e.g. "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256". The TLS 1.3
+	// suites are not configurable, so CipherSuites cannot be combined with
+	// MinVersion "1.3". When empty, the Go defaults are used.
+	CipherSuites []string `json:",omitempty"`
+}
+
+// Validate checks that the certificate, key and client CA files exist and
+// parse, and that the version and cipher suites are supported.
+func (t *TLS) Validate(configroot string) error {
+	_, err := t.ServerConfig(configroot)
+	return err
+}
+
+// ServerConfig builds the *tls.Config of a listener from the section.
+func (t *TLS) ServerConfig(configroot string) (*tls.Config, error) {
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, fmt.Errorf("TLS.CertFile and TLS.KeyFile are required")
+	}
+
+	certFile, err := tlsPath(configroot, t.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := tlsPath(configroot, t.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %s", err)
+	}
+
+	minVersion, ok := tlsVersions[t.MinVersion.WithDefault(DefaultTLSMinVersion)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS.MinVersion %q, must be one of \"1.2\" or \"1.3\"", t.MinVersion.WithDefault(DefaultTLSMinVersion))
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+	}
+
+	if len(t.CipherSuites) > 0 {
+		if minVersion == tls.VersionTLS13 {
+			return nil, fmt.Errorf("TLS.CipherSuites has no effect with TLS.MinVersion 1.3")
+		}
+		suites := make(map[string]uint16)
+		for _, cs := range tls.CipherSuites() {
+			for _, v := range cs.SupportedVersions {
+				if v == tls.VersionTLS12 {
+					suites[cs.Name] = cs.ID
+				}
+			}
+		}
+		for _, name := range t.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported or insecure TLS 1.2 cipher suite %q", name)
+			}
+			conf.CipherSuites = append(conf.CipherSuites, id)
+		}
+	}
+
+	if t.ClientCAFile != "" {
+		caFile, err := tlsPath(configroot, t.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS client CA file %s", caFile)
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return conf, nil
+}
+
+func tlsPath(configroot, file string) (string, error) {
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	return Path(configroot, file)
+}
+
+// ValidateTLS validates the TLS sections of the API, Gateway and
+// S3CompatibleAPI listeners, resolving relative paths against configroot.
+func (c *Config) ValidateTLS(configroot string) error {
+	for _, l := range []struct {
+		section string
+		tls     *TLS
+	}{
+		{"API", c.API.TLS},
+		{"Gateway", c.Gateway.TLS},
+		{"S3CompatibleAPI", c.S3CompatibleAPI.TLS},
+	} {
+		if l.tls == nil {
+			continue
+		}
+		if err := l.tls.Validate(configroot); err != nil {
+			return fmt.Errorf("%s: %w", l.section, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, dir string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), certPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCertificate(t, dir)
+
+	conf := &TLS{
+		CertFile:     "cert.pem",
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: "cert.pem",
+		MinVersion:   NewOptionalString("1.2"),
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	}
+	tc, err := conf.ServerConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected TLS 1.2, got %x", tc.MinVersion)
+	}
+	if tc.ClientAuth != tls.RequireAndVerifyClientCert || tc.ClientCAs == nil {
+		t.Fatal("expected mutual TLS to be configured")
+	}
+	if len(tc.CipherSuites) != 1 || tc.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("unexpected cipher suites %v", tc.CipherSuites)
+	}
+
+	for name, mod := range map[string]func(c TLS) TLS{
+		"missing key":      func(c TLS) TLS { c.KeyFile = "missing.pem"; return c },
+		"no key":           func(c TLS) TLS { c.KeyFile = ""; return c },
+		"invalid CA":       func(c TLS) TLS { c.ClientCAFile = "missing.pem"; return c },
+		"old version":      func(c TLS) TLS { c.MinVersion = NewOptionalString("1.0"); return c },
+		"insecure suite":   func(c TLS) TLS { c.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}; return c },
+		"key is not a key": func(c TLS) TLS { c.KeyFile = c.CertFile; return c },
+		"TLS 1.3 suite":    func(c TLS) TLS { c.CipherSuites = []string{"TLS_AES_128_GCM_SHA256"}; return c },
+		"TLS 1.3 only":     func(c TLS) TLS { c.MinVersion = NewOptionalString("1.3"); return c },
+	} {
+		bad := mod(*conf)
+		if err := bad.Validate(dir); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+
+	cfg := &Config{}
+	cfg.Gateway.TLS = &TLS{CertFile: "cert.pem", KeyFile: "missing.pem"}
+	if err := cfg.ValidateTLS(dir); err == nil || !strings.HasPrefix(err.Error(), "Gateway: ") {
+		t.Fatalf("expected an error for the Gateway section, got %v", err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -308,6 +311,11 @@ type OptionalInteger struct {
 	value *int64
 }
 
+// NewOptionalInteger returns an OptionalInteger from an int64
+func NewOptionalInteger(v int64) *OptionalInteger {
+	return &OptionalInteger{value: &v}
+}
+
 // WithDefault resolves the integer with the given default.
 func (p *OptionalInteger) WithDefault(defaultValue int64) (value int64) {
 	if p == nil || p.value == nil {
@@ -410,6 +418,187 @@ func (p OptionalString) String() string {
 var _ json.Unmarshaler = (*OptionalInteger)(nil)
 var _ json.Marshaler = (*OptionalInteger)(nil)
 
+// ByteSize is a size in bytes. It is encoded in JSON as a human-readable
+// string such as "10GB" or "512MiB", and decoded from either such a string
+// or a plain number of bytes.
+type ByteSize uint64
+
+// SI and IEC byte size units.
+const (
+	B  ByteSize = 1
+	KB          = 1000 * B
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+	EB          = 1000 * PB
+
+	KiB = 1024 * B
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+	EiB = 1024 * PiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"": B, "b": B,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+	"e": EB, "eb": EB, "ei": EiB, "eib": EiB,
+}
+
+// byteSizeNames lists the units used by ByteSize.String, largest first.
+var byteSizeNames = []struct {
+	unit ByteSize
+	name string
+}{
+	{EB, "EB"}, {EiB, "EiB"}, {PB, "PB"}, {PiB, "PiB"}, {TB, "TB"}, {TiB, "TiB"},
+	{GB, "GB"}, {GiB, "GiB"}, {MB, "MB"}, {MiB, "MiB"}, {KB, "kB"}, {KiB, "KiB"},
+}
+
+// ParseByteSize parses a size such as "10GB", "1.5 TiB" or "1024". Units are
+// case-insensitive; "k", "kB" and friends are SI (powers of 1000), "KiB" and
+// friends are IEC (powers of 1024). A number without a unit is in bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unitName := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	unit, ok := byteSizeUnits[unitName]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q: %s", s, err)
+		}
+		if n > math.MaxUint64/uint64(unit) {
+			return 0, fmt.Errorf("byte size %q is too large", s)
+		}
+		return ByteSize(n) * unit, nil
+	}
+	// decimals are parsed exactly, fractions of a byte are dropped.
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetUint64(uint64(unit)))
+	size := new(big.Int).Quo(r.Num(), r.Denom())
+	if !size.IsUint64() {
+		return 0, fmt.Errorf("byte size %q is too large", s)
+	}
+	return ByteSize(size.Uint64()), nil
+}
+
+// String returns the size in the largest unit that represents it exactly,
+// preferring SI units, e.g. "10GB", "512MiB" or "1234B".
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteSizeNames {
+		if b%u.unit == 0 {
+			return fmt.Sprintf("%d%s", b/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
+
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *ByteSize) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		var n uint64
+		if err := json.Unmarshal(input, &n); err != nil {
+			return fmt.Errorf("invalid byte size %s", input)
+		}
+		*b = ByteSize(n)
+		return nil
+	}
+	if s == "" {
+		*b = 0
+		return nil
+	}
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+var _ json.Unmarshaler = (*ByteSize)(nil)
+var _ json.Marshaler = (*ByteSize)(nil)
+
+// OptionalBytes represents a byte size that has a default value
+//
+// When encoded in json, Default is encoded as "null"
+type OptionalBytes struct {
+	value *ByteSize
+}
+
+// NewOptionalBytes returns an OptionalBytes from a ByteSize
+func NewOptionalBytes(b ByteSize) *OptionalBytes {
+	return &OptionalBytes{value: &b}
+}
+
+// WithDefault resolves the byte size with the given default.
+func (p *OptionalBytes) WithDefault(defaultValue ByteSize) (value ByteSize) {
+	if p == nil || p.value == nil {
+		return defaultValue
+	}
+	return *p.value
+}
+
+// IsDefault returns if this is a default optional byte size
+func (p *OptionalBytes) IsDefault() bool {
+	return p == nil || p.value == nil
+}
+
+func (p OptionalBytes) MarshalJSON() ([]byte, error) {
+	if p.value != nil {
+		return json.Marshal(p.value)
+	}
+	return json.Marshal(nil)
+}
+
+func (p *OptionalBytes) UnmarshalJSON(input []byte) error {
+	switch string(input) {
+	case "null", "undefined", "\"\"", "\"default\"":
+		*p = OptionalBytes{}
+	default:
+		var value ByteSize
+		if err := json.Unmarshal(input, &value); err != nil {
+			return err
+		}
+		*p = OptionalBytes{value: &value}
+	}
+	return nil
+}
+
+func (p OptionalBytes) String() string {
+	if p.value == nil {
+		return "default"
+	}
+	return p.value.String()
+}
+
+var _ json.Unmarshaler = (*OptionalBytes)(nil)
+var _ json.Marshaler = (*OptionalBytes)(nil)
+
 // doNotUse is a type you must not use, it should be struct{} but encoding/json
 // does not support omitempty on structs and I can't be bothered to write custom
 // marshalers on all structs that have a doNotUse field.
@@ -512,3 +512,90 @@ func TestOptionalString(t *testing.T) {
 		}
 	}
 }
+
+func TestByteSize(t *testing.T) {
+	for in, want := range map[string]ByteSize{
+		"10GB":     10 * GB,
+		"1TB":      TB,
+		"1 tb":     TB,
+		"512MiB":   512 * MiB,
+		"1.5GiB":   GiB + 512*MiB,
+		"2k":       2 * KB,
+		"4kB":      4 * KB,
+		"4KiB":     4 * KiB,
+		"1024":     1024,
+		"0":        0,
+		" 3 EiB ":  3 * EiB,
+		"8.2GB":    8200 * MB,
+		"2.01MB":   2010 * KB,
+		"0.1KB":    100,
+		"1.0005KB": 1000,
+	} {
+		got, err := ParseByteSize(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%q: expected %d, got %d", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "GB", "1.2.3GB", "-1GB", "20EB", "1XB", "16 bytes"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+
+	for b, want := range map[ByteSize]string{
+		0:             "0B",
+		10 * GB:       "10GB",
+		512 * MiB:     "512MiB",
+		1500 * MB:     "1500MB",
+		GiB + 512*MiB: "1536MiB",
+		1234:          "1234B",
+	} {
+		if got := b.String(); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+
+	var d Datastore
+	if err := json.Unmarshal([]byte(`{"StorageMax": "1.5 TB"}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.StorageMax != 1500*GB || d.StorageMax < TB {
+		t.Fatalf("unexpected StorageMax %s", d.StorageMax)
+	}
+	if err := json.Unmarshal([]byte(`{"StorageMax": 2048}`), &d); err != nil || d.StorageMax != 2*KiB {
+		t.Fatalf("expected a plain number of bytes to decode, got %s, %v", d.StorageMax, err)
+	}
+	if err := json.Unmarshal([]byte(`{"StorageMax": "lots"}`), &d); err == nil {
+		t.Fatal("expected an invalid size to fail decoding")
+	}
+	out, err := json.Marshal(ByteSize(10 * GB))
+	if err != nil || string(out) != `"10GB"` {
+		t.Fatalf("unexpected encoding %s, %v", out, err)
+	}
+}
+
+func TestOptionalBytes(t *testing.T) {
+	var r ResourceMgr
+	if err := json.Unmarshal([]byte(`{"MaxMemory": "4GiB"}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.MaxMemory.WithDefault(0) != 4*GiB {
+		t.Fatalf("unexpected MaxMemory %s", r.MaxMemory)
+	}
+	for _, in := range []string{`{}`, `{"MaxMemory": null}`, `{"MaxMemory": ""}`} {
+		var r ResourceMgr
+		if err := json.Unmarshal([]byte(in), &r); err != nil {
+			t.Fatal(err)
+		}
+		if !r.MaxMemory.IsDefault() || r.MaxMemory.WithDefault(GB) != GB {
+			t.Fatalf("%s: expected the default", in)
+		}
+	}
+	out, err := json.Marshal(NewOptionalBytes(256 * MB))
+	if err != nil || string(out) != `"256MB"` {
+		t.Fatalf("unexpected encoding %s, %v", out, err)
+	}
+}